@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	. "github.com/SimoneStefani/thesis-algorithms/utilities"
+)
+
+// runBlocks checks the Merkle root of every block of raw Bitcoin block
+// files against the one of its header:
+//
+//	thesis blocks blk00000.dat
+//
+// The files hold blocks one after the other or in the blk*.dat layout, see
+// ReadBlockFile. Without a file, or with -, the blocks are read from stdin.
+// It exits with exitError when a root does not match. With -tx the
+// merkleblock proving a transaction is printed in hex after its block.
+func runBlocks(args []string) error {
+	flags := newFlagSet("blocks", "thesis blocks [-tx=<txid>] [file...]")
+	tx := flags.String("tx", "", "the txid, as displayed, whose merkleblock to print")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{Stdio}
+	}
+
+	mismatches, count := 0, 0
+	for _, path := range paths {
+		blocks, err := readBlockFile(path)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+
+		for _, block := range blocks {
+			root, err := block.MerkleRoot()
+			if err != nil {
+				return err
+			}
+
+			status := "ok"
+			if valid, _ := block.CheckMerkleRoot(); !valid {
+				status = "mismatch, computed " + DisplayHash(root)
+				mismatches++
+			}
+			count++
+			fmt.Printf("%s txs=%d merkle_root=%s %s\n", DisplayHash(block.Hash()), len(block.TxIDs), DisplayHash(block.HeaderMerkleRoot()), status)
+
+			for i, txid := range block.TxIDs {
+				if *tx == "" || DisplayHash(txid) != *tx {
+					continue
+				}
+				merkleBlock, err := block.MerkleBlock(i)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("merkleblock %d: %s\n", i, hex.EncodeToString(merkleBlock))
+			}
+		}
+	}
+
+	if mismatches > 0 {
+		return fmt.Errorf("error: %d of %d blocks do not match the merkle root of their header", mismatches, count)
+	}
+	return nil
+}
+
+func readBlockFile(path string) ([]*Block, error) {
+	if path == Stdio {
+		return ReadBlockFile(os.Stdin)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadBlockFile(file)
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	. "github.com/SimoneStefani/thesis-algorithms/utilities"
+)
+
+// Exit codes of the commands.
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// usageError is returned for invalid arguments, which exit with exitUsage.
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+// subcommand is a command of the thesis binary: thesis [name] [flags].
+type subcommand struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+func subcommands() []subcommand {
+	return []subcommand{
+		{"bench", "run the build, prove and verify experiments selected with -op", runBenchCommand("")},
+		{"build", "run the build experiment", runBenchCommand("build")},
+		{"sweep", "run the experiments over several input sizes and fit their complexity", runSweep},
+		{"gen", "write seeded datasets to the source folder", runGen},
+		{"calibrate", "measure the overhead of reading the timer", runCalibrate},
+		{"root", "print the root or head digest of a transaction file", runRoot},
+		{"prove", "write the proof file of a transaction", runProve},
+		{"verify", "check a proof file against a root", runVerify},
+		{"inspect", "render a structure as DOT, ASCII or JSON", runInspect},
+		{"blocks", "check the merkle roots of raw Bitcoin block files", runBlocks},
+		{"serve", "run a signed transparency log over HTTP", runServe},
+	}
+}
+
+// runCLI dispatches args to their subcommand and returns the exit code.
+// Arguments starting with a flag are the flat form that predates the
+// subcommands: they run bench, and -algo=time runs calibrate.
+func runCLI(args []string) int {
+	if len(args) > 0 && (args[0] == "help" || args[0] == "-h") {
+		printUsage()
+		return exitOK
+	}
+
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return exitCode(runLegacy(args))
+	}
+
+	for _, c := range subcommands() {
+		if c.name == args[0] {
+			return exitCode(c.run(args[1:]))
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "error: unknown command %s\n\n", args[0])
+	printUsage()
+	return exitUsage
+}
+
+func printUsage() {
+	fmt.Fprintf(os.Stderr, "usage: thesis <command> [flags]\n\ncommands:\n")
+	for _, c := range subcommands() {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun thesis <command> -h for the flags of a command.\n")
+}
+
+// exitCode reports err and maps it to the exit code of the command.
+func exitCode(err error) int {
+	if err == nil || err == flag.ErrHelp {
+		return exitOK
+	}
+
+	fmt.Fprintln(os.Stderr, err)
+
+	var usage *usageError
+	if errors.As(err, &usage) {
+		return exitUsage
+	}
+	return exitError
+}
+
+// newFlagSet returns the flag set of a subcommand, whose parsing errors are
+// returned rather than exiting.
+func newFlagSet(name string, usage string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s\n\nflags:\n", usage)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parseFlags parses args and turns invalid flags into a usage error.
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return err
+		}
+		return &usageError{err.Error()}
+	}
+	return nil
+}
+
+// checkInput fails with a usage error for an input format ReadInput does not
+// understand.
+func checkInput(format string) error {
+	for _, f := range InputFormats {
+		if f == format {
+			return nil
+		}
+	}
+	return &usageError{"error: unknown input format " + format}
+}
+
+// parseBenchCommand parses the flags of the experiments and checks the
+// algorithm and operation before any data is loaded.
+func parseBenchCommand(flags *flag.FlagSet, op string, args []string) (*Command, error) {
+	cmd, err := ParseCommand(flags, op, args)
+	if err != nil {
+		if err == flag.ErrHelp {
+			return nil, err
+		}
+		return nil, &usageError{err.Error()}
+	}
+
+	if _, err := newStructure(cmd.Algorithm, 0, cmd.FalsePositiveRate); err != nil {
+		return nil, &usageError{err.Error()}
+	}
+	if op := cmd.Operation; op != "build" && op != "prove" && op != "verify" && op != "all" {
+		return nil, &usageError{"error: unknown operation " + op}
+	}
+	if f := cmd.Format; f != "txt" && f != "csv" && f != "json" {
+		return nil, &usageError{"error: unknown format " + f}
+	}
+	if cmd.Warmup < 0 {
+		return nil, &usageError{"error: -warmup must not be negative"}
+	}
+	if cmd.Positions != "" && cmd.Samples < 1 {
+		return nil, &usageError{"error: -samples must be at least 1"}
+	}
+	if err := checkInput(cmd.InputFormat); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+// runBenchCommand returns the command running the experiments, with the
+// operation fixed to op unless it is empty.
+func runBenchCommand(op string) func([]string) error {
+	return func(args []string) error {
+		name := "bench"
+		if op != "" {
+			name = op
+		}
+
+		flags := newFlagSet(name, "thesis "+name+" -algo=mt -name=uniform_samples_100.txt -iter=10")
+		cmd, err := parseBenchCommand(flags, op, args)
+		if err != nil {
+			return err
+		}
+		return runBench(cmd)
+	}
+}
+
+// runLegacy runs the flat flag form: thesis -algo=mt -op=all -name=... and
+// thesis -algo=time for the timer calibration.
+func runLegacy(args []string) error {
+	flags := newFlagSet("thesis", "thesis <command> [flags], or thesis -algo=mt -op=all -name=uniform_samples_100.txt -iter=10")
+	cmd, err := ParseCommand(flags, "", args)
+	if err != nil {
+		if err == flag.ErrHelp {
+			printUsage()
+			return err
+		}
+		return &usageError{err.Error()}
+	}
+
+	if cmd.Algorithm == "time" {
+		return runCalibrate(nil)
+	}
+	return runBenchCommand("")(args)
+}
+
+// runCalibrate measures the time between two consecutive readings of the
+// timer and writes the trials to time.txt in the results folder.
+func runCalibrate(args []string) error {
+	flags := newFlagSet("calibrate", "thesis calibrate [-out=results]")
+	out := flags.String("out", "results", "the folder of the result file, - for stdout")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Running time experiment...\n\n")
+	result := formatNullResults(evaluateVoid())
+	return writeOutput(*out, "time.txt", result, nil)
+}
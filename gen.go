@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	. "github.com/SimoneStefani/thesis-algorithms/utilities"
+)
+
+// runGen writes generated datasets to the folder given with -out, source
+// by default, one file per size:
+//
+//	thesis gen -kind=uniform -size=100,1000 -minlen=32 -maxlen=64 -seed=1
+//
+// Unless -name is given the files are named [kind]_samples_[size].txt,
+// e.g. uniform_samples_100.txt. With -out=- a single dataset is written to
+// stdout, one element per line.
+func runGen(args []string) error {
+	flags := newFlagSet("gen", "thesis gen -kind=uniform -size=100,1000 -minlen=32 -maxlen=64 -seed=1")
+
+	// Parse the kind of data:
+	// uniform -> random alphanumeric strings (default)
+	// sorted -> uniform strings in increasing order
+	// reverse -> uniform strings in decreasing order
+	// duplicates -> uniform strings where a fraction -dup repeat earlier ones
+	// bitcoin -> hex encoded raw transactions
+	kind := flags.String("kind", "uniform", "the kind of data (uniform, sorted, reverse, duplicates or bitcoin)")
+	sizes := flags.String("size", "100", "the number of elements, a comma separated list writes one file per size")
+	minLen := flags.Int("minlen", 64, "the minimum length of the strings")
+	maxLen := flags.Int("maxlen", 64, "the maximum length of the strings")
+	dup := flags.Float64("dup", 0.1, "the fraction of duplicated elements with -kind=duplicates")
+	seed := flags.Int64("seed", 1, "the seed of the generator")
+	name := flags.String("name", "", "the name of the output file, only with a single size")
+	out := flags.String("out", "source", "the folder of the output files, - for stdout")
+
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	n, err := ParseIntList(*sizes)
+	if err != nil {
+		return &usageError{err.Error()}
+	}
+	if *name != "" && len(n) != 1 {
+		return &usageError{fmt.Sprintf("error: -name requires a single size, got %d", len(n))}
+	}
+	if *out == Stdio && len(n) != 1 {
+		return &usageError{fmt.Sprintf("error: -out=- requires a single size, got %d", len(n))}
+	}
+
+	for _, size := range n {
+		data, err := GenerateData(*kind, size, *minLen, *maxLen, *dup, *seed)
+		if err != nil {
+			return err
+		}
+
+		fileName := *name
+		if fileName == "" {
+			fileName = *kind + "_samples_" + strconv.Itoa(size) + ".txt"
+		}
+
+		if *out == Stdio {
+			_, err := fmt.Println(strings.Join(data, "\n"))
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "Writing %d elements to %s\n", size, fileName)
+		if err := WriteData(filepath.Join(*out, fileName), strings.Join(data, "\n")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
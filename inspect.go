@@ -0,0 +1,499 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/SimoneStefani/thesis-algorithms/structures/asl"
+	. "github.com/SimoneStefani/thesis-algorithms/structures/common"
+	"github.com/SimoneStefani/thesis-algorithms/structures/fastmt"
+	"github.com/SimoneStefani/thesis-algorithms/structures/kmt"
+	"github.com/SimoneStefani/thesis-algorithms/structures/mt"
+	. "github.com/SimoneStefani/thesis-algorithms/utilities"
+)
+
+// Roles of the nodes of a graph with a highlighted element: the nodes the
+// proof recomputes and the nodes whose digests the proof carries.
+const (
+	rolePath    = "path"
+	roleSibling = "sibling"
+)
+
+// graphNode is a digest of a structure. Level 0 is the top of the
+// structure, the root of a tree or the top list of a skip list.
+type graphNode struct {
+	ID    int    `json:"id"`
+	Level int    `json:"level"`
+	Hash  string `json:"hash"`
+	Data  string `json:"data,omitempty"`
+	Edges []int  `json:"edges,omitempty"`
+	Role  string `json:"role,omitempty"`
+}
+
+// graph is the layout of a structure shared by the inspect formats.
+type graph struct {
+	Algorithm string      `json:"algorithm"`
+	Levels    int         `json:"levels"`
+	Nodes     []graphNode `json:"nodes"`
+}
+
+// inspector is a committer that can lay out its digests. pos is the index
+// of the element whose proof is highlighted, or -1.
+type inspector interface {
+	committer
+	graph(pos int) (*graph, error)
+}
+
+func (g *graph) add(level int, hash string, data string, role string) int {
+	g.Nodes = append(g.Nodes, graphNode{ID: len(g.Nodes), Level: level, Hash: hash, Data: data, Role: role})
+	if level+1 > g.Levels {
+		g.Levels = level + 1
+	}
+	return len(g.Nodes) - 1
+}
+
+func (g *graph) edge(from int, to int) {
+	g.Nodes[from].Edges = append(g.Nodes[from].Edges, to)
+}
+
+// hexHash converts a digest of the structures to hex.
+func hexHash(hash string) string {
+	raw, err := DecodeHash(hash)
+	if err != nil {
+		return hash
+	}
+	return hex.EncodeToString(raw)
+}
+
+// treeNode is a node of a pointer-based tree, whatever its package.
+type treeNode struct {
+	key      interface{}
+	hash     string
+	data     string
+	children []*treeNode
+	onPath   bool
+}
+
+// treeGraph lays out a tree level by level from the root. Nodes shared by
+// two parents, the duplicated last nodes, are added once. The nodes on the
+// path are the ones recomputed by the proof, their other children the ones
+// it carries.
+func treeGraph(algo string, root *treeNode) *graph {
+	g := &graph{Algorithm: algo}
+	ids := make(map[interface{}]int)
+
+	level := []*treeNode{root}
+	ids[root.key] = g.add(0, hexHash(root.hash), root.data, role(root, false))
+	for depth := 1; len(level) > 0; depth++ {
+		var next []*treeNode
+		for _, parent := range level {
+			for _, child := range parent.children {
+				id, ok := ids[child.key]
+				if !ok {
+					id = g.add(depth, hexHash(child.hash), child.data, role(child, parent.onPath))
+					ids[child.key] = id
+					next = append(next, child)
+				}
+				g.edge(ids[parent.key], id)
+			}
+		}
+		level = next
+	}
+	return g
+}
+
+func role(node *treeNode, parentOnPath bool) string {
+	if node.onPath {
+		return rolePath
+	}
+	if parentOnPath {
+		return roleSibling
+	}
+	return ""
+}
+
+func (s *merkleTree) graph(pos int) (*graph, error) {
+	path := make(map[*mt.Node]bool)
+	if pos >= 0 {
+		for node := s.tree.Leaves[pos]; node != nil; node = node.Parent {
+			path[node] = true
+		}
+	}
+
+	var convert func(node *mt.Node) *treeNode
+	convert = func(node *mt.Node) *treeNode {
+		t := &treeNode{key: node, hash: node.Hash(), data: node.Data(), onPath: path[node]}
+		for _, child := range []*mt.Node{node.Left, node.Right} {
+			if child != nil {
+				t.children = append(t.children, convert(child))
+			}
+		}
+		return t
+	}
+	return treeGraph("mt", convert(s.tree.Root)), nil
+}
+
+func (s *fastMerkleTree) graph(pos int) (*graph, error) {
+	path := make(map[*fastmt.Node]bool)
+	if pos >= 0 {
+		for node := s.tree.Leaves[pos]; node != nil; node = node.Parent {
+			path[node] = true
+		}
+	}
+
+	var convert func(node *fastmt.Node) *treeNode
+	convert = func(node *fastmt.Node) *treeNode {
+		t := &treeNode{key: node, hash: node.Hash(), data: node.Data(), onPath: path[node]}
+		for _, child := range []*fastmt.Node{node.Left, node.Right} {
+			if child != nil {
+				t.children = append(t.children, convert(child))
+			}
+		}
+		return t
+	}
+	return treeGraph("fmt", convert(s.tree.Root)), nil
+}
+
+func (s *karyMerkleTree) graph(pos int) (*graph, error) {
+	path := make(map[*kmt.Node]bool)
+	if pos >= 0 {
+		for node := s.tree.Leaves[pos]; node != nil; node = node.Parent {
+			path[node] = true
+		}
+	}
+
+	var convert func(node *kmt.Node) *treeNode
+	convert = func(node *kmt.Node) *treeNode {
+		t := &treeNode{key: node, hash: node.Hash(), data: node.Data(), onPath: path[node]}
+		for _, child := range node.Children {
+			t.children = append(t.children, convert(child))
+		}
+		return t
+	}
+	return treeGraph("kmt", convert(s.tree.Root)), nil
+}
+
+func (s *arrayMerkleTree) graph(pos int) (*graph, error) {
+	type key struct{ level, i int }
+	top := s.tree.Depth() - 1
+
+	var convert func(level int, i int, onPath bool) *treeNode
+	convert = func(level int, i int, onPath bool) *treeNode {
+		t := &treeNode{key: key{level, i}, hash: EncodeHash(s.tree.Hash(level, i)), onPath: onPath}
+		if level == 0 {
+			t.data = s.data[i]
+			return t
+		}
+
+		// the index of the element at the level below, if on the path
+		below := -1
+		if onPath {
+			below = pos >> uint(level-1)
+		}
+		left, right := 2*i, 2*i+1
+		if right >= s.tree.Width(level-1) {
+			right = left
+		}
+		t.children = append(t.children, convert(level-1, left, below == left))
+		t.children = append(t.children, convert(level-1, right, below == right))
+		return t
+	}
+	return treeGraph("amt", convert(top, 0, pos >= 0)), nil
+}
+
+// graph lays out the chain as a single row, every chain hash pointing to
+// the next one. The proof recomputes the hashes from the element on and
+// carries the one before it.
+func (s *hashList) graph(pos int) (*graph, error) {
+	g := &graph{Algorithm: "hl"}
+	for i, hash := range s.list.Chain() {
+		r := ""
+		if pos >= 0 && i >= pos {
+			r = rolePath
+		} else if pos >= 0 && i == pos-1 {
+			r = roleSibling
+		}
+		id := g.add(0, hexHash(hash), s.data[i], r)
+		if id > 0 {
+			g.edge(id-1, id)
+		}
+	}
+	return g, nil
+}
+
+// graph lays out the head on top of the checkpoints, each of them on top of
+// the leaves of its segment.
+func (s *checkpointHashList) graph(pos int) (*graph, error) {
+	segment := -1
+	if pos >= 0 {
+		segment = pos / s.list.Interval()
+	}
+
+	g := &graph{Algorithm: "chl"}
+	head := g.add(0, hexHash(s.list.HeadHash()), "", roleIf(pos >= 0, rolePath))
+	leaves := s.list.LeafHashes()
+	for c, checkpoint := range s.list.CheckpointHashes() {
+		cp := g.add(1, hexHash(checkpoint), "", roleIf(pos >= 0, roleSibling))
+		if c == segment {
+			g.Nodes[cp].Role = rolePath
+		}
+		g.edge(head, cp)
+
+		start := c * s.list.Interval()
+		end := int(math.Min(float64(start+s.list.Interval()), float64(len(leaves))))
+		for i := start; i < end; i++ {
+			r := ""
+			if c == segment {
+				r = roleIf(i == pos, rolePath)
+				if r == "" {
+					r = roleSibling
+				}
+			}
+			g.edge(cp, g.add(2, hexHash(leaves[i]), s.data[i], r))
+		}
+	}
+	return g, nil
+}
+
+func roleIf(condition bool, r string) string {
+	if condition {
+		return r
+	}
+	return ""
+}
+
+// graph lays out the lists from the top one, every node pointing to the
+// next one of its list and to its copy in the list below. The proof
+// recomputes the authenticators of the base nodes it hops through.
+func (s *skipList) graph(pos int) (*graph, error) {
+	hops := make(map[int]bool)
+	if pos >= 0 {
+		last := s.list.Length() - 1
+		for index := pos; ; {
+			hops[index] = true
+			if index >= last {
+				break
+			}
+			index += 1 << uint(asl.SingleHopTraversalLevel(index, last-1))
+		}
+	}
+
+	g := &graph{Algorithm: "sl"}
+	levels := s.list.Levels()
+	above := make(map[int]int)
+	for level := levels - 1; level >= 0; level-- {
+		current := make(map[int]int)
+		prev := -1
+		for _, node := range s.list.Nodes(level) {
+			r := roleIf(level == 0 && hops[node.Index()], rolePath)
+			id := g.add(levels-1-level, hexHash(node.Authenticator()), node.Transaction(), r)
+			if prev >= 0 {
+				g.edge(prev, id)
+			}
+			if up, ok := above[node.Index()]; ok {
+				g.edge(up, id)
+			}
+			current[node.Index()] = id
+			prev = id
+		}
+		above = current
+	}
+	return g, nil
+}
+
+// shortHash truncates a hex digest to n characters, 0 keeps it whole.
+func shortHash(hash string, n int) string {
+	if n > 0 && n < len(hash) {
+		return hash[:n]
+	}
+	return hash
+}
+
+// writeDot renders the graph for Graphviz, one rank per level. Nodes on the
+// proof path are filled in gold and the digests carried by the proof in
+// light blue.
+func writeDot(w io.Writer, g *graph, hashLen int) error {
+	fmt.Fprintf(w, "digraph %s {\n", g.Algorithm)
+	fmt.Fprintf(w, "  node [shape=box, fontname=\"monospace\"];\n")
+	for _, node := range g.Nodes {
+		label := shortHash(node.Hash, hashLen)
+		if node.Data != "" {
+			label = label + "\\n" + dotLabel(node.Data)
+		}
+		style := ""
+		switch node.Role {
+		case rolePath:
+			style = ", style=filled, fillcolor=gold"
+		case roleSibling:
+			style = ", style=filled, fillcolor=lightblue"
+		}
+		fmt.Fprintf(w, "  n%d [label=\"%s\"%s];\n", node.ID, label, style)
+	}
+	for _, node := range g.Nodes {
+		for _, to := range node.Edges {
+			fmt.Fprintf(w, "  n%d -> n%d;\n", node.ID, to)
+		}
+	}
+	for level := 0; level < g.Levels; level++ {
+		var ids []string
+		for _, node := range g.Nodes {
+			if node.Level == level {
+				ids = append(ids, fmt.Sprintf("n%d", node.ID))
+			}
+		}
+		fmt.Fprintf(w, "  { rank=same; %s; }\n", strings.Join(ids, "; "))
+	}
+	_, err := fmt.Fprintf(w, "}\n")
+	return err
+}
+
+// dotLabel escapes a transaction for a quoted DOT label. Transactions read
+// as hex, base64, prefixed or blocks may hold any bytes: unless they are
+// printable text they are shown in hex.
+func dotLabel(data string) string {
+	printable := utf8.ValidString(data)
+	for _, r := range data {
+		printable = printable && unicode.IsPrint(r)
+	}
+	if !printable {
+		return "0x" + hex.EncodeToString([]byte(data))
+	}
+
+	return strings.NewReplacer("\\", "\\\\", "\"", "\\\"").Replace(data)
+}
+
+// writeASCII renders one line per level. Nodes on the proof path are marked
+// with * and the digests carried by the proof with +.
+func writeASCII(w io.Writer, g *graph, hashLen int) error {
+	levels := make([][]string, g.Levels)
+	for _, node := range g.Nodes {
+		label := shortHash(node.Hash, hashLen)
+		if node.Data != "" {
+			label = label + "(" + node.Data + ")"
+		}
+		switch node.Role {
+		case rolePath:
+			label = "*" + label
+		case roleSibling:
+			label = "+" + label
+		}
+		levels[node.Level] = append(levels[node.Level], label)
+	}
+
+	fmt.Fprintf(w, "%s\n", g.Algorithm)
+	for level, labels := range levels {
+		if _, err := fmt.Fprintf(w, "%3d: %s\n", level, strings.Join(labels, "  ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeGraphJSON(w io.Writer, g *graph, hashLen int) error {
+	for i := range g.Nodes {
+		g.Nodes[i].Hash = shortHash(g.Nodes[i].Hash, hashLen)
+	}
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// runInspect builds a structure from a transaction file and renders it:
+//
+//	thesis inspect -algo=mt -format=dot -element=tx42 transactions.txt | dot -Tsvg > mt.svg
+//
+// Without a file, or with -, the transactions are read from stdin.
+func runInspect(args []string) error {
+	flags := newFlagSet("inspect", "thesis inspect [flags] [file]")
+
+	algo := flags.String("algo", "mt", "the structure to build (mt, fmt, amt, kmt, hl, chl or sl)")
+	k := flags.Int("k", 0, "the structure parameter, the arity of kmt (default 2) or the checkpoint interval of chl (default sqrt of the input size)")
+	format := flags.String("format", "ascii", "the output format (ascii, dot or json)")
+	hashLen := flags.Int("hashlen", 8, "the number of hex characters of the digests to show, 0 for all")
+	index := flags.Int("index", -1, "the index of the transaction whose proof is highlighted")
+	element := flags.String("element", "", "the transaction whose proof is highlighted, instead of -index")
+	input, maxLineSize := InputFlags(flags)
+
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return &usageError{"error: inspect expects at most one file"}
+	}
+	if *index >= 0 && *element != "" {
+		return &usageError{"error: inspect expects -index or -element, not both"}
+	}
+	write := map[string]func(io.Writer, *graph, int) error{
+		"ascii": writeASCII,
+		"dot":   writeDot,
+		"json":  writeGraphJSON,
+	}[*format]
+	if write == nil {
+		return &usageError{"error: unknown format " + *format}
+	}
+	if err := checkInput(*input); err != nil {
+		return err
+	}
+	if _, err := newStructure(*algo, 0, 0); err != nil {
+		return &usageError{err.Error()}
+	}
+
+	data, err := readTransactions(flags.Arg(0), *input, *maxLineSize)
+	if err != nil {
+		return err
+	}
+	if *algo == "sl" {
+		sort.Strings(data)
+	}
+
+	pos := *index
+	if *element != "" {
+		pos = -1
+		for i, tr := range data {
+			if tr == *element {
+				pos = i
+				break
+			}
+		}
+		if pos < 0 {
+			return errors.New("error: " + *element + " is not part of the transactions")
+		}
+	}
+	if pos >= len(data) {
+		return fmt.Errorf("error: index %d out of range, %d transactions", pos, len(data))
+	}
+
+	param := *k
+	if param == 0 {
+		param = structureParameters(*algo, nil, len(data))[0]
+	}
+	s, err := newStructure(*algo, param, 0)
+	if err != nil {
+		return err
+	}
+	in, ok := s.(inspector)
+	if !ok {
+		return &usageError{"error: " + *algo + " cannot be inspected"}
+	}
+	if err := in.build(data); err != nil {
+		return err
+	}
+
+	g, err := in.graph(pos)
+	if err != nil {
+		return err
+	}
+	return write(os.Stdout, g, *hashLen)
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteDotEscapesTransactions(t *testing.T) {
+	g := &graph{Algorithm: "hl"}
+	g.add(0, "aa", "C:\\tx \"1\"", "")
+	g.add(0, "bb", "line\nbreak\x00", "")
+
+	var buffer bytes.Buffer
+	writeDot(&buffer, g, 0)
+	dot := buffer.String()
+
+	if !strings.Contains(dot, `label="aa\nC:\\tx \"1\""`) {
+		t.Error("Expected escaped backslash and quotes, got " + dot)
+	}
+
+	if !strings.Contains(dot, `label="bb\n0x6c696e650a627265616b00"`) {
+		t.Error("Expected bytes that aren't text in hex, got " + dot)
+	}
+}
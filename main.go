@@ -1,17 +1,19 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"runtime"
 	"runtime/debug"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
-	"github.com/SimoneStefani/thesis-algorithms/structures/asl"
-	"github.com/SimoneStefani/thesis-algorithms/structures/fastmt"
-	"github.com/SimoneStefani/thesis-algorithms/structures/hashlist"
-	"github.com/SimoneStefani/thesis-algorithms/structures/mt"
+	"github.com/SimoneStefani/thesis-algorithms/structures/chl"
+	. "github.com/SimoneStefani/thesis-algorithms/structures/common"
 	. "github.com/SimoneStefani/thesis-algorithms/utilities"
 )
 
@@ -22,7 +24,7 @@ func main() {
 	//
 	// test := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l", "m", "n", "o"}
 	// sl, _ := asl.NewSkipList(test)
-	// asl.PrintListAuthenticators(*sl)
+	// asl.PrintListAuthenticators(os.Stdout, *sl)
 	// fmt.Print("\n")
 
 	// //Print Examples for Searching the Skip List
@@ -58,57 +60,237 @@ func main() {
 
 	// return
 
-	// get absolute path of current folder
-	basePath := GetPath()
+	os.Exit(runCLI(os.Args[1:]))
+}
 
-	// parse the command line arguments
-	algo, op, fileName, iter := ParseCommand()
-	if *algo == "time" {
-		fmt.Printf("Running time experiment...\n\n")
-		result := formatNullResults(evaluateVoid())
-		WriteData(basePath+"/results/time.txt", result)
-		return
+// runBench runs the experiment described by cmd on every input file matching
+// its name and writes the result files.
+func runBench(cmd *Command) error {
+	inputs, err := ResolveInputs(cmd.InputDir, cmd.FileName)
+	if err != nil {
+		return err
+	}
+	if len(inputs) == 1 {
+		return runBenchInput(cmd, inputs[0])
+	}
+
+	// carry on with the other inputs but fail the command
+	failed := 0
+	for _, path := range inputs {
+		if err := runBenchInput(cmd, path); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			failed++
+		}
 	}
-	fmt.Printf("Running experiment with algo=%s and op=%s from %s...\n\n", *algo, *op, *fileName)
+	if failed > 0 {
+		return fmt.Errorf("error: %d of %d inputs failed", failed, len(inputs))
+	}
+	return nil
+}
 
-	// load data from specific file
-	sourcePath := basePath + "/source/" + *fileName
-	data := LoadData(sourcePath)
+// runBenchInput runs the experiment described by cmd on the input at path
+// once for every structure parameter and writes the result files.
+func runBenchInput(cmd *Command, path string) error {
+	algo := cmd.Algorithm
+	inputName := filepath.Base(path)
+	if path == Stdio {
+		inputName = "stdin"
+	}
+	fmt.Fprintf(os.Stderr, "Running experiment with algo=%s and op=%s from %s...\n\n", algo, cmd.Operation, inputName)
 
-	// run experiment
-	buildTimeResults, buildMemResults, veriTimeResults, veriMemResults := runExperiment(data, algo, *iter)
+	data, err := LoadInput(path, cmd.InputFormat, cmd.MaxLineSize)
+	if err != nil {
+		return err
+	}
 
-	// write to file the stringified result.
-	// output file name pattern: result_[algo]_[inputName]
-	// e.g. result_mt_uniform_samples_100.txt
-	result := formatResults(buildTimeResults, buildMemResults, veriTimeResults, veriMemResults)
-	resultName := "result_" + *algo + "_" + *fileName
+	// the skip list lookup walks its levels in order
+	if algo == "sl" {
+		sort.Strings(data)
+	}
 
-	WriteData(basePath+"/results/"+resultName, result)
+	// the median time between two consecutive readings of the timer
+	var overhead int64
+	if cmd.SubtractOverhead {
+		overhead = Median(evaluateVoid())
+		fmt.Fprintf(os.Stderr, "Subtracting timer overhead of %dns\n\n", overhead)
+	}
+
+	// describe the run in the json results
+	meta := &runMetadata{
+		Algorithm:    algo,
+		Operation:    cmd.Operation,
+		HashFunction: hashFunction(algo),
+		InputFile:    inputName,
+		InputSize:    len(data),
+		Iterations:   cmd.Iterations,
+		Warmup:       cmd.Warmup,
+		Positions:    cmd.Positions,
+		GoVersion:    runtime.Version(),
+		GOMAXPROCS:   runtime.GOMAXPROCS(0),
+		CPUModel:     cpuModel(),
+		GitCommit:    gitCommit(),
+	}
+	out, err := newOutput(cmd.Format, meta)
+	if err != nil {
+		return err
+	}
+
+	// run the experiment once for every structure parameter, e.g. -k=2,4,8,16
+	var failed error
+	for _, param := range structureParameters(algo, cmd.Parameters, len(data)) {
+		newS := func() (structure, error) {
+			return newStructure(algo, param, cmd.FalsePositiveRate)
+		}
+		s, err := newS()
+		if err != nil {
+			return err
+		}
+
+		results, err := runExperiment(data, newS, cmd)
+		if err != nil {
+			// carry on with the other parameters but fail the command
+			fmt.Fprintln(os.Stderr, err)
+			failed = err
+			continue
+		}
+		results.subtractOverhead(overhead)
+		meta.Parameter = param
+		meta.Timestamp = time.Now().UTC().Format(time.RFC3339)
+
+		// write to file the stringified result.
+		// output file name pattern: result_[algo]_[inputName] for op=all
+		// and result_[algo]_[op]_[inputName] for a single phase
+		// e.g. result_mt_uniform_samples_100.txt
+		// parametrised structures add the parameter to the algo
+		// e.g. result_kmt-k4_prove_uniform_samples_100.txt
+		// csv and json results replace the extension of the input name
+		// e.g. result_mt_uniform_samples_100.json
+		resultName := algo
+		if param > 0 {
+			resultName = resultName + "-k" + strconv.Itoa(param)
+		}
+		if cmd.Operation != "all" {
+			resultName = resultName + "_" + cmd.Operation
+		}
+
+		content, err := out.results(results.columnNames(), results.averages())
+		if err = writeOutput(cmd.OutputDir, out.fileName("result", resultName, inputName), content, err); err != nil {
+			return err
+		}
+
+		// the statistics of every column of the result file
+		// output file name pattern: summary_[algo]_[inputName]
+		content, err = out.summary(results.columnNames(), results.averages(), cmd.Seed)
+		if err = writeOutput(cmd.OutputDir, out.fileName("summary", resultName, inputName), content, err); err != nil {
+			return err
+		}
+
+		// the results of every sampled element, so that proofs can be
+		// plotted against the index of the element
+		// output file name pattern: positions_[algo]_[inputName]
+		if cmd.Operation != "build" {
+			content, err = out.positions(results)
+			if err = writeOutput(cmd.OutputDir, out.fileName("positions", resultName, inputName), content, err); err != nil {
+				return err
+			}
+		}
+
+		// filters also report how often they wrongly claim membership
+		// output file name pattern: fpr_[algo]_[inputName]
+		if f, ok := s.(filter); ok && cmd.Operation != "build" {
+			target, measured, err := runFalsePositiveExperiment(data, f)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "False positive rate: target %g, measured %g\n", target, measured)
+			content, err = out.falsePositives(target, measured)
+			if err = writeOutput(cmd.OutputDir, out.fileName("fpr", algo, inputName), content, err); err != nil {
+				return err
+			}
+		}
+	}
+
+	return failed
 }
 
-func formatResults(build_t []int64, build_m []int64, veri_t []int64, veri_m []int64) string {
-	results := ""
-	for i := 0; i < len(build_t); i++ {
-		if i+1 == len(build_t) {
-			results = results + strconv.FormatInt(build_t[i], 10) + ", " + strconv.FormatInt(build_m[i], 10) + ", " + strconv.FormatInt(veri_t[i], 10) + ", " + strconv.FormatInt(veri_m[i], 10)
-		} else {
-			results = results + strconv.FormatInt(build_t[i], 10) + ", " + strconv.FormatInt(build_m[i], 10) + ", " + strconv.FormatInt(veri_t[i], 10) + ", " + strconv.FormatInt(veri_m[i], 10) + "\n"
+// structureParameters returns the values of k to sweep for algo, or a single
+// zero for structures without a parameter.
+func structureParameters(algo string, k []int, size int) []int {
+	if algo == "chl" {
+		if len(k) == 0 {
+			// default to the checkpoint interval with the smallest proofs
+			return []int{chl.OptimalInterval(size)}
+		}
+		return k
+	}
+	if algo == "kmt" {
+		if len(k) == 0 {
+			return []int{2, 4, 8, 16}
 		}
+		return k
 	}
-	return results
+	return []int{0}
 }
 
-func formatNullResults(timeTrials []int64) string {
-	results := ""
-	for i := 0; i < len(timeTrials); i++ {
-		if i+1 == len(timeTrials) {
-			results = results + strconv.FormatInt(timeTrials[i], 10)
-		} else {
-			results = results + strconv.FormatInt(timeTrials[i], 10) + "\n"
+// formatResults lays out one row per trial and one column per result list,
+// separated by commas.
+func formatResults(columns ...[]int64) string {
+	var rows []string
+	for i := 0; len(columns) > 0 && i < len(columns[0]); i++ {
+		var row []string
+		for _, column := range columns {
+			row = append(row, strconv.FormatInt(column[i], 10))
 		}
+		rows = append(rows, strings.Join(row, ", "))
 	}
-	return results
+	return strings.Join(rows, "\n")
+}
+
+// formatPositionResults lays out one row per trial and sampled element: the
+// index of the element followed by the proof and verification results.
+func formatPositionResults(results *experimentResults) string {
+	var rows []string
+	for i := 0; i < results.iterations; i++ {
+		for j, pos := range results.positions {
+			row := []string{strconv.Itoa(pos)}
+			for _, column := range results.positionColumns() {
+				row = append(row, strconv.FormatInt(column[i][j], 10))
+			}
+			rows = append(rows, strings.Join(row, ", "))
+		}
+	}
+	return strings.Join(rows, "\n")
+}
+
+// formatSummary lays out one row per result column: its name followed by
+// the count, mean, median, standard deviation, 95th and 99th percentiles
+// and the bounds of the 95% confidence interval of the mean.
+func formatSummary(names []string, columns [][]int64, seed int64) string {
+	var rows []string
+	for i, column := range columns {
+		rows = append(rows, strings.Join(summaryRow(names[i], Summarize(column, seed)), ", "))
+	}
+	return strings.Join(rows, "\n")
+}
+
+func summaryRow(name string, s Summary) []string {
+	row := []string{name, strconv.Itoa(s.Count)}
+	for _, value := range []float64{s.Mean, s.Median, s.StdDev, s.P95, s.P99, s.CILow, s.CIHigh} {
+		row = append(row, strconv.FormatFloat(value, 'f', 2, 64))
+	}
+	return row
+}
+
+func formatFalsePositiveResults(target float64, measured float64) string {
+	return strconv.FormatFloat(target, 'g', -1, 64) + ", " + strconv.FormatFloat(measured, 'g', -1, 64)
+}
+
+func formatNullResults(timeTrials []int64) string {
+	results := make([]string, len(timeTrials))
+	for i, trial := range timeTrials {
+		results[i] = strconv.FormatInt(trial, 10)
+	}
+	return strings.Join(results, "\n")
 }
 
 func evaluateVoid() []int64 {
@@ -132,113 +314,357 @@ func evaluateVoid() []int64 {
 	return timeTrials
 }
 
-func runExperiment(data []string, algo *string, iter int) ([]int64, []int64, []int64, []int64) {
+// experimentResults holds the trials of the phases that were run. The proof
+// and verification trials hold one result per sampled position.
+type experimentResults struct {
+	iterations         int
+	positions          []int
+	buildTime          []int64
+	buildMem           []int64
+	buildAllocations   []int64
+	buildRetained      []int64
+	proofTime          [][]int64
+	proofMem           [][]int64
+	proofSize          [][]int64
+	proofLength        [][]int64
+	verificationTime   [][]int64
+	verificationMem    [][]int64
+	verificationHashes [][]int64
+}
 
-	buildTime, buildMem := runBuildExperiment(data, algo, iter)
-	verificationTime, verificationMem := runVerificationExperiment(data, algo, iter)
+// averages returns the columns of the result file, in the order of the
+// phases, averaging the proof and verification over the sampled positions:
+// build -> build time, build memory, build allocations, build retained
+// prove -> proof time, proof memory, proof size, proof length
+// verify -> verification time, verification memory, verification hashes
+func (r *experimentResults) averages() [][]int64 {
+	var columns [][]int64
+	if r.buildTime != nil {
+		columns = append(columns, r.buildTime, r.buildMem, r.buildAllocations, r.buildRetained)
+	}
+	for _, column := range r.positionColumns() {
+		columns = append(columns, average(column))
+	}
+	return columns
+}
+
+// columnNames returns the names of the columns returned by averages.
+func (r *experimentResults) columnNames() []string {
+	var names []string
+	if r.buildTime != nil {
+		names = append(names, "build_time", "build_memory", "build_allocations", "build_retained")
+	}
+	return append(names, r.positionColumnNames()...)
+}
+
+// positionColumnNames returns the names of the columns returned by
+// positionColumns.
+func (r *experimentResults) positionColumnNames() []string {
+	var names []string
+	if r.proofTime != nil {
+		names = append(names, "proof_time", "proof_memory", "proof_size", "proof_length")
+	}
+	if r.verificationTime != nil {
+		names = append(names, "verification_time", "verification_memory", "verification_hashes")
+	}
+	return names
+}
 
-	return buildTime, buildMem, verificationTime, verificationMem
+// subtractOverhead removes the timer overhead from every time result,
+// without letting a result drop below zero.
+func (r *experimentResults) subtractOverhead(overhead int64) {
+	subtract := func(trials []int64) {
+		for i := range trials {
+			trials[i] -= overhead
+			if trials[i] < 0 {
+				trials[i] = 0
+			}
+		}
+	}
+
+	subtract(r.buildTime)
+	for i := range r.proofTime {
+		subtract(r.proofTime[i])
+	}
+	for i := range r.verificationTime {
+		subtract(r.verificationTime[i])
+	}
 }
 
-func runBuildExperiment(data []string, algo *string, iter int) ([]int64, []int64) {
+// discardWarmup drops the results of the first n iterations.
+func (r *experimentResults) discardWarmup(n int) {
+	if r.buildTime != nil {
+		r.buildTime, r.buildMem = r.buildTime[n:], r.buildMem[n:]
+		r.buildAllocations, r.buildRetained = r.buildAllocations[n:], r.buildRetained[n:]
+	}
+	if r.proofTime != nil {
+		r.proofTime, r.proofMem = r.proofTime[n:], r.proofMem[n:]
+		r.proofSize, r.proofLength = r.proofSize[n:], r.proofLength[n:]
+	}
+	if r.verificationTime != nil {
+		r.verificationTime, r.verificationMem = r.verificationTime[n:], r.verificationMem[n:]
+		r.verificationHashes = r.verificationHashes[n:]
+	}
+	r.iterations -= n
+}
+
+// positionColumns returns the proof and verification trials that were run.
+func (r *experimentResults) positionColumns() [][][]int64 {
+	var columns [][][]int64
+	if r.proofTime != nil {
+		columns = append(columns, r.proofTime, r.proofMem, r.proofSize, r.proofLength)
+	}
+	if r.verificationTime != nil {
+		columns = append(columns, r.verificationTime, r.verificationMem, r.verificationHashes)
+	}
+	return columns
+}
+
+func average(trials [][]int64) []int64 {
+	var averages []int64
+	for _, trial := range trials {
+		var sum int64
+		for _, value := range trial {
+			sum += value
+		}
+		averages = append(averages, sum/int64(len(trial)))
+	}
+	return averages
+}
+
+// runExperiment runs the phases selected by cmd.Operation:
+// build -> build the structure
+// prove -> generate the proofs of the sampled positions
+// verify -> check the proofs of the sampled positions
+// all -> the three of them
+// The first cmd.Warmup iterations of every phase are run and then discarded.
+func runExperiment(data []string, newS func() (structure, error), cmd *Command) (*experimentResults, error) {
+	op := cmd.Operation
+	iter := cmd.Iterations + cmd.Warmup
+	results := &experimentResults{iterations: iter}
+	var err error
+
+	if op != "build" && op != "prove" && op != "verify" && op != "all" {
+		return nil, errors.New("error: unknown operation " + op)
+	}
+
+	if op == "build" || op == "all" {
+		results.buildTime, results.buildMem, results.buildAllocations, results.buildRetained, err = runBuildExperiment(data, newS, iter)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if op == "build" {
+		results.discardWarmup(cmd.Warmup)
+		return results, nil
+	}
+
+	s, err := newS()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.build(data); err != nil {
+		return nil, err
+	}
+	results.positions, err = samplePositions(cmd.Positions, s, len(data), cmd.Samples, cmd.Seed)
+	if err != nil {
+		return nil, err
+	}
+
+	if op == "prove" || op == "all" {
+		results.proofTime, results.proofMem, results.proofSize, results.proofLength, err = runProofExperiment(s, results.positions, iter)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if op == "verify" || op == "all" {
+		results.verificationTime, results.verificationMem, results.verificationHashes, err = runVerificationExperiment(s, results.positions, iter)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	results.discardWarmup(cmd.Warmup)
+	return results, nil
+}
+
+// runBuildExperiment builds a new structure in every iteration. Besides time
+// it returns the bytes and the number of objects allocated while building,
+// and the live heap still held by the finished structure after a collection.
+func runBuildExperiment(data []string, newS func() (structure, error), iter int) ([]int64, []int64, []int64, []int64, error) {
 	var timeTrials []int64
 	var memTrials []int64
+	var allocationTrials []int64
+	var retainedTrials []int64
 
 	for i := 0; i < iter; i++ {
-		var start time.Time
-		var t time.Time
+		s, err := newS()
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
 
 		runtime.GC()
-		debug.SetGCPercent(-1)
-		b := GetMemUsage()
+		old := debug.SetGCPercent(-1)
+		b := GetMemStats()
 
-		if *algo == "mt" {
-			start = time.Now()
-			mt.NewTree(data)
-			t = time.Now()
-		} else if *algo == "hl" {
-			start = time.Now()
-			hashlist.NewHashList(data)
-			t = time.Now()
-		} else if *algo == "fmt" {
-			start = time.Now()
-			fastmt.NewFastMerkleTree(data)
-			t = time.Now()
-		} else if *algo == "sl" {
-			sort.Strings(data)
-			start = time.Now()
-			asl.NewSkipList(data)
-			t = time.Now()
+		start := time.Now()
+		err = s.build(data)
+		t := time.Now()
+
+		a := GetMemStats()
+		debug.SetGCPercent(old)
+
+		if err != nil {
+			return nil, nil, nil, nil, err
 		}
 
-		a := GetMemUsage()
+		// only the structure survives the collection, small structures can
+		// be outweighed by runtime objects freed in the meantime
+		runtime.GC()
+		retained := GetMemStats().Live - b.Live
+		runtime.KeepAlive(s)
+		if retained < 0 {
+			retained = 0
+		}
 
 		timeTrials = append(timeTrials, t.Sub(start).Nanoseconds())
-		memTrials = append(memTrials, a-b)
+		memTrials = append(memTrials, a.Allocated-b.Allocated)
+		allocationTrials = append(allocationTrials, a.Allocations-b.Allocations)
+		retainedTrials = append(retainedTrials, retained)
 	}
-	return timeTrials, memTrials
+	return timeTrials, memTrials, allocationTrials, retainedTrials, nil
 }
 
-func runVerificationExperiment(data []string, algo *string, iter int) ([]int64, []int64) {
-	var timeTrials []int64
-	var memTrials []int64
-	averageTimePosition := len(data) / 2
+// runProofExperiment expects a built structure. Besides time and memory it
+// returns the serialized size of the proofs in bytes and their length in
+// hashes or proof components.
+func runProofExperiment(s structure, positions []int, iter int) ([][]int64, [][]int64, [][]int64, [][]int64, error) {
+	var timeTrials [][]int64
+	var memTrials [][]int64
+	var sizeTrials [][]int64
+	var lengthTrials [][]int64
 
 	for i := 0; i < iter; i++ {
-		var start time.Time
-		var t time.Time
-		var b int64
+		var times []int64
+		var mems []int64
+		var sizes []int64
+		var lengths []int64
 
-		runtime.GC()
+		for _, pos := range positions {
+			runtime.GC()
+			old := debug.SetGCPercent(-1)
+			b := GetMemStats()
 
-		if *algo == "mt" {
-			tree, _ := mt.NewTree(data)
-			start = time.Now()
-			root, path, _, _ := mt.VerifyTransaction(data[averageTimePosition], data, tree)
-			t = time.Now()
+			start := time.Now()
+			p, err := s.prove(pos)
+			t := time.Now()
 
-			runtime.GC()
-			debug.SetGCPercent(-1)
-			b = GetMemUsage()
-			mt.CheckPath(data[averageTimePosition], root, path)
-		} else if *algo == "hl" {
-			start = time.Now()
-			root, path, _, _ := hashlist.VerifyTransaction(data[averageTimePosition], data)
-			t = time.Now()
+			a := GetMemStats()
+			debug.SetGCPercent(old)
 
-			runtime.GC()
-			debug.SetGCPercent(-1)
-			b = GetMemUsage()
-			hashlist.CheckPath(data[averageTimePosition], root, path)
-		} else if *algo == "fmt" {
-			tree, _ := fastmt.NewFastMerkleTree(data)
-			start = time.Now()
-			root, path, _, _ := fastmt.VerifyTransaction(data[averageTimePosition], data, tree)
-			t = time.Now()
+			if err != nil {
+				return nil, nil, nil, nil, err
+			}
+			times = append(times, t.Sub(start).Nanoseconds())
+			mems = append(mems, a.Allocated-b.Allocated)
+			data, err := p.marshal()
+			if err != nil {
+				return nil, nil, nil, nil, err
+			}
+			sizes = append(sizes, int64(len(data)))
+			lengths = append(lengths, int64(p.length()))
+		}
 
+		timeTrials = append(timeTrials, times)
+		memTrials = append(memTrials, mems)
+		sizeTrials = append(sizeTrials, sizes)
+		lengthTrials = append(lengthTrials, lengths)
+	}
+	return timeTrials, memTrials, sizeTrials, lengthTrials, nil
+}
+
+// runVerificationExperiment expects a built structure and only measures
+// checking the proofs, which are generated beforehand. Besides time and
+// memory it returns the number of SHA-256 invocations of every check.
+func runVerificationExperiment(s structure, positions []int, iter int) ([][]int64, [][]int64, [][]int64, error) {
+	var timeTrials [][]int64
+	var memTrials [][]int64
+	var hashTrials [][]int64
+
+	var proofs []proof
+	for _, pos := range positions {
+		p, err := s.prove(pos)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		proofs = append(proofs, p)
+	}
+
+	for i := 0; i < iter; i++ {
+		var times []int64
+		var mems []int64
+		var hashes []int64
+
+		for j, p := range proofs {
 			runtime.GC()
-			debug.SetGCPercent(-1)
-			b = GetMemUsage()
-			fastmt.CheckPath(data[averageTimePosition], root, path)
-		} else if *algo == "sl" {
-			sort.Strings(data)
-			sl, _ := asl.NewSkipList(data)
-			start = time.Now()
-			_, proof, nodePointer, _ := asl.VerifyTransaction(*sl, data[averageTimePosition])
-			asl.VerifyTransaction(*sl, data[averageTimePosition]) //Swap with the above if input is sorted
-			t = time.Now()
-			runtime.GC()
-			debug.SetGCPercent(-1)
-			b = GetMemUsage()
-			asl.VerifyMembershipProof(*nodePointer, *sl, proof)
+			old := debug.SetGCPercent(-1)
+			b := GetMemStats()
+			h := HashCount()
+
+			start := time.Now()
+			valid := p.verify()
+			t := time.Now()
+
+			a := GetMemStats()
+			debug.SetGCPercent(old)
+
+			if !valid {
+				return nil, nil, nil, errors.New("error: proof of element " + strconv.Itoa(positions[j]) + " is not valid")
+			}
+			times = append(times, t.Sub(start).Nanoseconds())
+			mems = append(mems, a.Allocated-b.Allocated)
+			hashes = append(hashes, int64(HashCount()-h))
 		}
 
-		a := GetMemUsage()
-		timeTrials = append(timeTrials, t.Sub(start).Nanoseconds())
-		memTrials = append(memTrials, a-b)
+		timeTrials = append(timeTrials, times)
+		memTrials = append(memTrials, mems)
+		hashTrials = append(hashTrials, hashes)
 	}
+	return timeTrials, memTrials, hashTrials, nil
+}
 
-	// fmt.Printf("%d transactions - Average time over %d samples: %v\n", len(data), iter, avg)
+// falsePositiveProbes is the number of non-member lookups used to measure
+// the false positive rate of a filter.
+const falsePositiveProbes = 100000
+
+// runFalsePositiveExperiment builds the filter and looks up strings that are
+// not part of data. It returns the target rate (the expected one for filters
+// that cannot be tuned) and the fraction of probes that the filter wrongly
+// reported as members.
+func runFalsePositiveExperiment(data []string, f filter) (float64, float64, error) {
+	members := make(map[string]bool, len(data))
+	for _, tr := range data {
+		members[tr] = true
+	}
+
+	if err := f.build(data); err != nil {
+		return 0, 0, err
+	}
+
+	positives := 0
+	probes := 0
+	for i := 0; probes < falsePositiveProbes; i++ {
+		probe := "probe-" + strconv.Itoa(i)
+		if members[probe] {
+			continue
+		}
+		if f.test(probe) {
+			positives++
+		}
+		probes++
+	}
 
-	return timeTrials, memTrials
+	return f.falsePositiveRate(), float64(positives) / float64(probes), nil
 }
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// runMetadata describes the run that produced a result file, so that the
+// JSON results can be analysed without parsing their file names.
+type runMetadata struct {
+	Algorithm    string `json:"algorithm"`
+	Parameter    int    `json:"parameter,omitempty"`
+	Operation    string `json:"operation"`
+	HashFunction string `json:"hash_function"`
+	InputFile    string `json:"input_file"`
+	InputSize    int    `json:"input_size"`
+	Iterations   int    `json:"iterations"`
+	Warmup       int    `json:"warmup"`
+	Positions    string `json:"positions"`
+	GoVersion    string `json:"go_version"`
+	GOMAXPROCS   int    `json:"gomaxprocs"`
+	CPUModel     string `json:"cpu_model"`
+	Timestamp    string `json:"timestamp"`
+	GitCommit    string `json:"git_commit"`
+}
+
+// hashFunction returns the hash function the structure of algo is built on.
+func hashFunction(algo string) string {
+	switch algo {
+	case "bf":
+		return "MurmurHash3 (BIP 37)"
+	case "cf", "xf":
+		return "FNV-1a 64"
+	}
+	return "SHA-256"
+}
+
+// cpuModel returns the model name of the first processor listed in
+// /proc/cpuinfo, or the architecture where the file is not available.
+func cpuModel() string {
+	file, err := os.Open("/proc/cpuinfo")
+	if err != nil {
+		return runtime.GOARCH
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), ":", 2)
+		if len(fields) == 2 && strings.TrimSpace(fields[0]) == "model name" {
+			return strings.TrimSpace(fields[1])
+		}
+	}
+	return runtime.GOARCH
+}
+
+// commit is the git commit the binary was built from, stamped with
+//
+//	go build -ldflags "-X main.commit=$(git rev-parse HEAD)" -o thesis *.go
+var commit string
+
+// gitCommit returns the commit the binary was built from: the stamped one,
+// else the revision recorded by the go command when building a module, or
+// an empty string when neither is known.
+func gitCommit() string {
+	if commit != "" {
+		return commit
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				return setting.Value
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	. "github.com/SimoneStefani/thesis-algorithms/utilities"
+)
+
+// output renders the result files in the format selected with -format:
+// txt -> comma separated values without header (default)
+// csv -> comma separated values with a header naming the columns
+// json -> the run metadata followed by the named columns and their rows
+type output struct {
+	format string
+	meta   *runMetadata
+}
+
+func newOutput(format string, meta *runMetadata) (*output, error) {
+	if format != "txt" && format != "csv" && format != "json" {
+		return nil, errors.New("error: unknown format " + format)
+	}
+	return &output{format, meta}, nil
+}
+
+// fileName returns the name of a result file with the given prefix, e.g.
+// result_mt_uniform_samples_100.txt. The csv and json formats replace the
+// extension of the input file with their own.
+func (o *output) fileName(prefix string, resultName string, inputName string) string {
+	name := prefix + "_" + resultName + "_" + inputName
+	if o.format == "txt" {
+		return name
+	}
+	return strings.TrimSuffix(name, filepath.Ext(inputName)) + "." + o.format
+}
+
+type jsonTable struct {
+	*runMetadata
+	Columns []string  `json:"columns"`
+	Rows    [][]int64 `json:"rows"`
+}
+
+type jsonSummaryRow struct {
+	Column string `json:"column"`
+	Summary
+}
+
+type jsonSummary struct {
+	*runMetadata
+	Rows []jsonSummaryRow `json:"rows"`
+}
+
+type jsonFalsePositives struct {
+	*runMetadata
+	TargetRate   float64 `json:"target_rate"`
+	MeasuredRate float64 `json:"measured_rate"`
+}
+
+// results renders the result file, one row per trial.
+func (o *output) results(names []string, columns [][]int64) (string, error) {
+	switch o.format {
+	case "csv":
+		return formatCSV(names, transpose(columns))
+	case "json":
+		return formatJSON(&jsonTable{o.meta, names, transpose(columns)})
+	}
+	return formatResults(columns...), nil
+}
+
+// positions renders the results of every sampled element, one row per
+// trial and element.
+func (o *output) positions(results *experimentResults) (string, error) {
+	names := append([]string{"index"}, results.positionColumnNames()...)
+
+	var rows [][]int64
+	for i := 0; i < results.iterations; i++ {
+		for j, pos := range results.positions {
+			row := []int64{int64(pos)}
+			for _, column := range results.positionColumns() {
+				row = append(row, column[i][j])
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	switch o.format {
+	case "csv":
+		return formatCSV(names, rows)
+	case "json":
+		return formatJSON(&jsonTable{o.meta, names, rows})
+	}
+	return formatPositionResults(results), nil
+}
+
+// summary renders the statistics of every column of the result file.
+func (o *output) summary(names []string, columns [][]int64, seed int64) (string, error) {
+	switch o.format {
+	case "csv":
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		w.Write([]string{"column", "count", "mean", "median", "stddev", "p95", "p99", "ci_low", "ci_high"})
+		for i, column := range columns {
+			w.Write(summaryRow(names[i], Summarize(column, seed)))
+		}
+		w.Flush()
+		return buf.String(), w.Error()
+	case "json":
+		s := &jsonSummary{runMetadata: o.meta}
+		for i, column := range columns {
+			s.Rows = append(s.Rows, jsonSummaryRow{names[i], Summarize(column, seed)})
+		}
+		return formatJSON(s)
+	}
+	return formatSummary(names, columns, seed), nil
+}
+
+// falsePositives renders the target and measured false positive rates.
+func (o *output) falsePositives(target float64, measured float64) (string, error) {
+	switch o.format {
+	case "csv":
+		return "target_rate,measured_rate\n" + strconv.FormatFloat(target, 'g', -1, 64) + "," + strconv.FormatFloat(measured, 'g', -1, 64) + "\n", nil
+	case "json":
+		return formatJSON(&jsonFalsePositives{o.meta, target, measured})
+	}
+	return formatFalsePositiveResults(target, measured), nil
+}
+
+// writeOutput writes a rendered result file named name to the folder dir,
+// or returns why it could not be rendered or written. When dir is Stdio the
+// file is written to stdout after a line with its name.
+func writeOutput(dir string, name string, content string, err error) error {
+	if err != nil {
+		return err
+	}
+	if dir == Stdio {
+		if !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		_, err := fmt.Printf("# %s\n%s", name, content)
+		return err
+	}
+	return WriteData(filepath.Join(dir, name), content)
+}
+
+func formatCSV(header []string, rows [][]int64) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	w.Write(header)
+	for _, row := range rows {
+		var record []string
+		for _, value := range row {
+			record = append(record, strconv.FormatInt(value, 10))
+		}
+		w.Write(record)
+	}
+
+	w.Flush()
+	return buf.String(), w.Error()
+}
+
+func formatJSON(v interface{}) (string, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
+
+// transpose turns result columns into rows, one per trial.
+func transpose(columns [][]int64) [][]int64 {
+	var rows [][]int64
+	for i := 0; len(columns) > 0 && i < len(columns[0]); i++ {
+		var row []int64
+		for _, column := range columns {
+			row = append(row, column[i])
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
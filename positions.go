@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"math/rand"
+)
+
+// worstCaser is implemented by the structures whose longest proof is known
+// without proving every element.
+type worstCaser interface {
+	worstPosition(size int) int
+}
+
+// samplePositions returns the indices of the elements to prove and verify:
+// middle -> the element in the middle of the data (default)
+// ends -> the first, middle and last elements
+// all -> every element
+// random -> 'samples' elements drawn uniformly with the given seed
+// worst -> the element with the longest proof, which requires the built
+// structure in order to prove every element once
+func samplePositions(mode string, s structure, size int, samples int, seed int64) ([]int, error) {
+	switch mode {
+	case "middle":
+		return []int{size / 2}, nil
+	case "ends":
+		return []int{0, size / 2, size - 1}, nil
+	case "all":
+		positions := make([]int, size)
+		for i := range positions {
+			positions[i] = i
+		}
+		return positions, nil
+	case "random":
+		r := rand.New(rand.NewSource(seed))
+		positions := make([]int, samples)
+		for i := range positions {
+			positions[i] = r.Intn(size)
+		}
+		return positions, nil
+	case "worst":
+		if w, ok := s.(worstCaser); ok {
+			return []int{w.worstPosition(size)}, nil
+		}
+		worst, longest := 0, -1
+		for i := 0; i < size; i++ {
+			p, err := s.prove(i)
+			if err != nil {
+				return nil, err
+			}
+			if p.length() > longest {
+				worst, longest = i, p.length()
+			}
+		}
+		return []int{worst}, nil
+	}
+	return nil, errors.New("error: unknown position mode " + mode)
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/SimoneStefani/thesis-algorithms/structures/arraymt"
+	"github.com/SimoneStefani/thesis-algorithms/structures/asl"
+	"github.com/SimoneStefani/thesis-algorithms/structures/chl"
+	. "github.com/SimoneStefani/thesis-algorithms/structures/common"
+	"github.com/SimoneStefani/thesis-algorithms/structures/fastmt"
+	"github.com/SimoneStefani/thesis-algorithms/structures/hashlist"
+	"github.com/SimoneStefani/thesis-algorithms/structures/kmt"
+	"github.com/SimoneStefani/thesis-algorithms/structures/mt"
+	. "github.com/SimoneStefani/thesis-algorithms/utilities"
+)
+
+// proofFileMagic starts every proof file.
+var proofFileMagic = []byte("THPF")
+
+// proofFile is the membership proof of one transaction as exchanged between
+// the prove and verify commands. The proof is encoded with the Marshal
+// function of the package of the structure.
+type proofFile struct {
+	algo   string
+	index  int
+	length int
+	tr     string
+	proof  []byte
+}
+
+// marshal encodes the proof file as the magic, the algorithm, the index of
+// the transaction, the number of transactions, the transaction and the
+// proof. Strings and the proof are prefixed with their length, all numbers
+// are varints.
+func (pf *proofFile) marshal() []byte {
+	buffer := append([]byte(nil), proofFileMagic...)
+	buffer = appendBytes(buffer, []byte(pf.algo))
+	buffer = binary.AppendUvarint(buffer, uint64(pf.index))
+	buffer = binary.AppendUvarint(buffer, uint64(pf.length))
+	buffer = appendBytes(buffer, []byte(pf.tr))
+	return appendBytes(buffer, pf.proof)
+}
+
+func unmarshalProofFile(data []byte) (*proofFile, error) {
+	if !bytes.HasPrefix(data, proofFileMagic) {
+		return nil, errors.New("error: not a proof file")
+	}
+	r := bytes.NewReader(data[len(proofFileMagic):])
+
+	algo, err := readBytes(r)
+	if err != nil {
+		return nil, err
+	}
+	index, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, errors.New("error: truncated proof file")
+	}
+	length, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, errors.New("error: truncated proof file")
+	}
+	tr, err := readBytes(r)
+	if err != nil {
+		return nil, err
+	}
+	proof, err := readBytes(r)
+	if err != nil {
+		return nil, err
+	}
+	if r.Len() != 0 {
+		return nil, errors.New("error: trailing bytes in proof file")
+	}
+
+	return &proofFile{string(algo), int(index), int(length), string(tr), proof}, nil
+}
+
+func appendBytes(buffer []byte, data []byte) []byte {
+	buffer = binary.AppendUvarint(buffer, uint64(len(data)))
+	return append(buffer, data...)
+}
+
+func readBytes(r *bytes.Reader) ([]byte, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil || n > uint64(r.Len()) {
+		return nil, errors.New("error: truncated proof file")
+	}
+	data := make([]byte, n)
+	r.Read(data)
+	return data, nil
+}
+
+// check verifies the proof against the root or head digest of the
+// structure, without the transactions it was built from.
+func (pf *proofFile) check(root []byte) (bool, error) {
+	switch pf.algo {
+	case "mt":
+		path, err := mt.UnmarshalPath(pf.proof)
+		return err == nil && mt.CheckPath(pf.tr, EncodeHash(root), path), err
+	case "fmt":
+		path, err := fastmt.UnmarshalPath(pf.proof)
+		return err == nil && fastmt.CheckPath(pf.tr, EncodeHash(root), path), err
+	case "amt":
+		path, err := arraymt.UnmarshalPath(pf.proof)
+		return err == nil && arraymt.CheckPath(pf.tr, root, path), err
+	case "kmt":
+		path, err := kmt.UnmarshalPath(pf.proof)
+		return err == nil && kmt.CheckPath(pf.tr, EncodeHash(root), path), err
+	case "hl":
+		path, err := hashlist.UnmarshalPath(pf.proof)
+		return err == nil && hashlist.CheckPath(pf.tr, EncodeHash(root), path), err
+	case "chl":
+		proof, err := chl.UnmarshalProof(pf.proof)
+		return err == nil && chl.CheckPath(pf.tr, EncodeHash(root), proof), err
+	case "sl":
+		proof, err := asl.UnmarshalProof(pf.proof)
+		return err == nil && asl.CheckProof(pf.tr, pf.index, pf.length, EncodeHash(root), proof), err
+	}
+	return false, errors.New("error: " + pf.algo + " has no proofs")
+}
+
+// runProve builds a structure from a transaction file and writes the proof
+// of one of its transactions, chosen by its index or by its value:
+//
+//	thesis prove -algo=mt -element=tx42 -out=tx42.proof transactions.txt
+//
+// Without a file, or with -, the transactions are read from stdin. The root
+// to verify the proof against is printed, on stderr with -out=- since the
+// proof is then written to stdout.
+func runProve(args []string) error {
+	flags := newFlagSet("prove", "thesis prove [flags] [file]")
+
+	algo := flags.String("algo", "mt", "the structure to build (mt, fmt, amt, kmt, hl, chl or sl)")
+	k := flags.Int("k", 0, "the structure parameter, the arity of kmt (default 2) or the checkpoint interval of chl (default sqrt of the input size)")
+	index := flags.Int("index", -1, "the index of the transaction to prove")
+	element := flags.String("element", "", "the transaction to prove, instead of -index")
+	out := flags.String("out", "proof.bin", "the proof file to write, - for stdout")
+	encoding := flags.String("encoding", "hex", "the encoding of the printed root (hex or base64)")
+	input, maxLineSize := InputFlags(flags)
+
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return &usageError{"error: prove expects at most one file"}
+	}
+	if (*index < 0) == (*element == "") {
+		return &usageError{"error: prove expects either -index or -element"}
+	}
+	if *encoding != "hex" && *encoding != "base64" {
+		return &usageError{"error: unknown encoding " + *encoding}
+	}
+	if err := checkInput(*input); err != nil {
+		return err
+	}
+	if _, err := newStructure(*algo, 0, 0); err != nil {
+		return &usageError{err.Error()}
+	}
+
+	data, err := readTransactions(flags.Arg(0), *input, *maxLineSize)
+	if err != nil {
+		return err
+	}
+	if *algo == "sl" {
+		sort.Strings(data)
+	}
+
+	pos := *index
+	if *element != "" {
+		for i, tr := range data {
+			if tr == *element {
+				pos = i
+				break
+			}
+		}
+		if pos < 0 {
+			return errors.New("error: " + *element + " is not part of the transactions")
+		}
+	}
+	if pos >= len(data) {
+		return fmt.Errorf("error: index %d out of range, %d transactions", pos, len(data))
+	}
+
+	param := *k
+	if param == 0 {
+		param = structureParameters(*algo, nil, len(data))[0]
+	}
+	s, err := newStructure(*algo, param, 0)
+	if err != nil {
+		return err
+	}
+	c, ok := s.(committer)
+	if !ok {
+		return &usageError{"error: " + *algo + " has no proofs"}
+	}
+	if err := c.build(data); err != nil {
+		return err
+	}
+
+	p, err := c.prove(pos)
+	if err != nil {
+		return err
+	}
+	encoded, err := p.marshal()
+	if err != nil {
+		return err
+	}
+	root, err := c.root()
+	if err != nil {
+		return err
+	}
+
+	// the root goes to stderr when stdout carries the proof
+	pf := &proofFile{*algo, pos, len(data), data[pos], encoded}
+	if *out == Stdio {
+		if _, err := os.Stdout.Write(pf.marshal()); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "root: %s\n", encodeDigest(root, *encoding))
+		return nil
+	}
+	if err := WriteData(*out, string(pf.marshal())); err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote the proof of transaction %d to %s\n", pos, *out)
+	fmt.Printf("root: %s\n", encodeDigest(root, *encoding))
+	return nil
+}
+
+// runVerify checks a proof file written by prove against a root:
+//
+//	thesis verify -root=dea58cac... tx42.proof
+//
+// It exits with exitOK when the proof is valid and exitError otherwise.
+func runVerify(args []string) error {
+	flags := newFlagSet("verify", "thesis verify -root=<digest> [flags] <proof file or ->")
+
+	rootFlag := flags.String("root", "", "the root or head digest to verify against")
+	encoding := flags.String("encoding", "hex", "the encoding of the root (hex or base64)")
+
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return &usageError{"error: verify expects one proof file"}
+	}
+	if *rootFlag == "" {
+		return &usageError{"error: verify expects -root"}
+	}
+
+	root, err := decodeDigest(*rootFlag, *encoding)
+	if err != nil {
+		return &usageError{err.Error()}
+	}
+
+	var data []byte
+	if flags.Arg(0) == Stdio {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(flags.Arg(0))
+	}
+	if err != nil {
+		return err
+	}
+	pf, err := unmarshalProofFile(data)
+	if err != nil {
+		return err
+	}
+
+	valid, err := pf.check(root)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return fmt.Errorf("error: the proof of %s is not valid for %s", pf.describe(), *rootFlag)
+	}
+
+	fmt.Printf("The proof of %s (%s) is valid\n", pf.describe(), pf.algo)
+	return nil
+}
+
+// describe names the proven transaction by its index only when the proof
+// commits to it, as the ones of sl do: the index of the other proof files
+// is not checked and any file could change it, so they are named by their
+// transaction.
+func (pf *proofFile) describe() string {
+	if pf.algo == "sl" {
+		return fmt.Sprintf("transaction %d", pf.index)
+	}
+	if len(pf.tr) > 32 {
+		return fmt.Sprintf("transaction %q...", pf.tr[:32])
+	}
+	return fmt.Sprintf("transaction %q", pf.tr)
+}
+
+func encodeDigest(digest []byte, encoding string) string {
+	if encoding == "base64" {
+		return base64.StdEncoding.EncodeToString(digest)
+	}
+	return hex.EncodeToString(digest)
+}
+
+func decodeDigest(digest string, encoding string) ([]byte, error) {
+	var raw []byte
+	var err error
+	switch encoding {
+	case "hex":
+		raw, err = hex.DecodeString(digest)
+	case "base64":
+		raw, err = base64.StdEncoding.DecodeString(digest)
+	default:
+		return nil, errors.New("error: unknown encoding " + encoding)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) != HashSize {
+		return nil, fmt.Errorf("error: expected a digest of %d bytes, got %d", HashSize, len(raw))
+	}
+	return raw, nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	. "github.com/SimoneStefani/thesis-algorithms/utilities"
+)
+
+// runRoot builds a structure from a transaction file, one transaction per
+// line, and prints its root or head digest:
+//
+//	thesis root -algo=mt -encoding=hex transactions.txt
+//
+// Without a file, or with -, the transactions are read from stdin. The skip
+// list is built from the sorted transactions, like in the experiments.
+func runRoot(args []string) error {
+	flags := newFlagSet("root", "thesis root [flags] [file]")
+
+	algo := flags.String("algo", "mt", "the structure to build (mt, fmt, amt, kmt, hl, chl or sl)")
+	k := flags.Int("k", 0, "the structure parameter, the arity of kmt (default 2) or the checkpoint interval of chl (default sqrt of the input size)")
+	encoding := flags.String("encoding", "hex", "the encoding of the digest (hex or base64)")
+	depth := flags.Bool("depth", false, "also print the depth of the structure (not for hl and chl)")
+	count := flags.Bool("count", false, "also print the number of transactions")
+	input, maxLineSize := InputFlags(flags)
+
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return &usageError{"error: root expects at most one file"}
+	}
+	if *encoding != "hex" && *encoding != "base64" {
+		return &usageError{"error: unknown encoding " + *encoding}
+	}
+	if err := checkInput(*input); err != nil {
+		return err
+	}
+	if _, err := newStructure(*algo, 0, 0); err != nil {
+		return &usageError{err.Error()}
+	}
+
+	data, err := readTransactions(flags.Arg(0), *input, *maxLineSize)
+	if err != nil {
+		return err
+	}
+	if *algo == "sl" {
+		sort.Strings(data)
+	}
+
+	param := *k
+	if param == 0 {
+		param = structureParameters(*algo, nil, len(data))[0]
+	}
+	s, err := newStructure(*algo, param, 0)
+	if err != nil {
+		return err
+	}
+	c, ok := s.(committer)
+	if !ok {
+		return &usageError{"error: " + *algo + " has no root"}
+	}
+	if _, ok := c.(depther); *depth && !ok {
+		return &usageError{"error: " + *algo + " has no depth"}
+	}
+	if err := c.build(data); err != nil {
+		return err
+	}
+
+	root, err := c.root()
+	if err != nil {
+		return err
+	}
+	digest := encodeDigest(root, *encoding)
+
+	// a bare digest is easier to compare in scripts
+	if !*depth && !*count {
+		fmt.Println(digest)
+		return nil
+	}
+
+	fmt.Printf("root: %s\n", digest)
+	if *depth {
+		fmt.Printf("depth: %d\n", c.(depther).depth())
+	}
+	if *count {
+		fmt.Printf("leaves: %d\n", c.leaves())
+	}
+	return nil
+}
+
+// readTransactions reads the transactions in the given format from the file
+// at path, or from stdin when path is empty or -.
+func readTransactions(path string, format string, maxLineSize int) ([]string, error) {
+	if path != "" && path != "-" {
+		return LoadInput(path, format, maxLineSize)
+	}
+	elements, err := ReadInput(os.Stdin, format, maxLineSize)
+	if err != nil {
+		return nil, err
+	}
+	return Leaves(elements), nil
+}
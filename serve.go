@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/SimoneStefani/thesis-algorithms/tlog"
+)
+
+// runServe runs a transparency log over HTTP:
+//
+//	thesis serve -addr=localhost:8080 -dir=log
+//
+// The entries and the signing key are kept in -dir, so that a restarted
+// server serves the same log, see tlog.Open for the files and
+// tlog.NewHandler for the endpoints.
+func runServe(args []string) error {
+	flags := newFlagSet("serve", "thesis serve [-addr=localhost:8080] [-dir=log]")
+	addr := flags.String("addr", "localhost:8080", "the address to listen on")
+	dir := flags.String("dir", "log", "the folder of the entries and the key of the log")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	l, err := tlog.Open(*dir)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+
+	fmt.Fprintf(os.Stderr, "Serving the log in %s (%d entries) on http://%s\n", *dir, l.Size(), *addr)
+	fmt.Fprintf(os.Stderr, "Public key: %s\n", hex.EncodeToString(l.PublicKey()))
+	return http.ListenAndServe(*addr, tlog.NewHandler(l))
+}
@@ -0,0 +1,499 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/SimoneStefani/thesis-algorithms/structures/arraymt"
+	"github.com/SimoneStefani/thesis-algorithms/structures/asl"
+	"github.com/SimoneStefani/thesis-algorithms/structures/bloom"
+	"github.com/SimoneStefani/thesis-algorithms/structures/chl"
+	. "github.com/SimoneStefani/thesis-algorithms/structures/common"
+	"github.com/SimoneStefani/thesis-algorithms/structures/cuckoo"
+	"github.com/SimoneStefani/thesis-algorithms/structures/fastmt"
+	"github.com/SimoneStefani/thesis-algorithms/structures/hashlist"
+	"github.com/SimoneStefani/thesis-algorithms/structures/kmt"
+	"github.com/SimoneStefani/thesis-algorithms/structures/mt"
+	"github.com/SimoneStefani/thesis-algorithms/structures/xorfilter"
+)
+
+// structure wraps one of the data structures so that the experiments can
+// build it and prove the membership of its elements without knowing its API.
+type structure interface {
+	build(data []string) error
+	prove(pos int) (proof, error)
+}
+
+// proof is a membership proof bound to the element and the root it was
+// produced for, so that it can be checked without the structure. Its length
+// is the number of hashes or proof components it carries, marshal encodes
+// it with the encoding of its package.
+type proof interface {
+	verify() bool
+	length() int
+	marshal() ([]byte, error)
+}
+
+// filter is a probabilistic membership structure. Filters have no proofs:
+// their proof only records the element and verifying it is a lookup.
+type filter interface {
+	structure
+	test(tr string) bool
+	falsePositiveRate() float64
+}
+
+// committer is a structure committing to its data with a single digest, the
+// root of a tree or the head of a list.
+type committer interface {
+	structure
+	root() ([]byte, error)
+	leaves() int
+}
+
+// depther is a committer whose digest sits on top of a number of levels.
+type depther interface {
+	depth() int
+}
+
+// newStructure returns the structure for algo. The parameter k is the
+// checkpoint interval of chl and the arity of kmt, fpr is the target false
+// positive rate of bf.
+func newStructure(algo string, k int, fpr float64) (structure, error) {
+	switch algo {
+	case "mt":
+		return &merkleTree{}, nil
+	case "fmt":
+		return &fastMerkleTree{}, nil
+	case "amt":
+		return &arrayMerkleTree{}, nil
+	case "kmt":
+		return &karyMerkleTree{arity: k}, nil
+	case "hl":
+		return &hashList{}, nil
+	case "chl":
+		return &checkpointHashList{interval: k}, nil
+	case "sl":
+		return &skipList{}, nil
+	case "bf":
+		return &bloomFilter{fpr: fpr}, nil
+	case "cf":
+		return &cuckooFilter{}, nil
+	case "xf":
+		return &xorFilter{}, nil
+	}
+	return nil, errors.New("error: unknown algorithm " + algo)
+}
+
+type merkleTree struct {
+	data []string
+	tree *mt.MerkleTree
+}
+
+type merkleTreeProof struct {
+	tr   string
+	root string
+	path []mt.VerificationNode
+}
+
+func (s *merkleTree) build(data []string) (err error) {
+	s.data = data
+	s.tree, err = mt.NewTree(data)
+	return err
+}
+
+func (s *merkleTree) prove(pos int) (proof, error) {
+	path, err := s.tree.Prove(pos)
+	return &merkleTreeProof{s.data[pos], s.tree.MerkleRoot(), path}, err
+}
+
+func (s *merkleTree) root() ([]byte, error) {
+	return DecodeHash(s.tree.MerkleRoot())
+}
+
+func (s *merkleTree) leaves() int {
+	return len(s.data)
+}
+
+func (s *merkleTree) depth() int {
+	return s.tree.Root.Depth()
+}
+
+func (p *merkleTreeProof) verify() bool {
+	return mt.CheckPath(p.tr, p.root, p.path)
+}
+
+func (p *merkleTreeProof) length() int {
+	return len(p.path)
+}
+
+func (p *merkleTreeProof) marshal() ([]byte, error) {
+	return mt.MarshalPath(p.path)
+}
+
+type fastMerkleTree struct {
+	data []string
+	tree *fastmt.FastMerkleTree
+}
+
+type fastMerkleTreeProof struct {
+	tr   string
+	root string
+	path []fastmt.VerificationNode
+}
+
+func (s *fastMerkleTree) build(data []string) (err error) {
+	s.data = data
+	s.tree, err = fastmt.NewFastMerkleTree(data)
+	return err
+}
+
+func (s *fastMerkleTree) prove(pos int) (proof, error) {
+	path, err := s.tree.Prove(pos)
+	return &fastMerkleTreeProof{s.data[pos], s.tree.MerkleRoot(), path}, err
+}
+
+func (s *fastMerkleTree) root() ([]byte, error) {
+	return DecodeHash(s.tree.MerkleRoot())
+}
+
+func (s *fastMerkleTree) leaves() int {
+	return len(s.data)
+}
+
+func (s *fastMerkleTree) depth() int {
+	return s.tree.Root.Depth()
+}
+
+func (p *fastMerkleTreeProof) verify() bool {
+	return fastmt.CheckPath(p.tr, p.root, p.path)
+}
+
+func (p *fastMerkleTreeProof) length() int {
+	return len(p.path)
+}
+
+func (p *fastMerkleTreeProof) marshal() ([]byte, error) {
+	return fastmt.MarshalPath(p.path)
+}
+
+type arrayMerkleTree struct {
+	data []string
+	tree *arraymt.ArrayMerkleTree
+}
+
+type arrayMerkleTreeProof struct {
+	tr   string
+	root []byte
+	path []arraymt.VerificationNode
+}
+
+func (s *arrayMerkleTree) build(data []string) (err error) {
+	s.data = data
+	s.tree, err = arraymt.NewArrayMerkleTree(data)
+	return err
+}
+
+func (s *arrayMerkleTree) prove(pos int) (proof, error) {
+	path, err := s.tree.Prove(pos)
+	return &arrayMerkleTreeProof{s.data[pos], s.tree.MerkleRoot(), path}, err
+}
+
+func (s *arrayMerkleTree) root() ([]byte, error) {
+	return s.tree.MerkleRoot(), nil
+}
+
+func (s *arrayMerkleTree) leaves() int {
+	return s.tree.Leaves()
+}
+
+func (s *arrayMerkleTree) depth() int {
+	return s.tree.Depth()
+}
+
+func (p *arrayMerkleTreeProof) verify() bool {
+	return arraymt.CheckPath(p.tr, p.root, p.path)
+}
+
+func (p *arrayMerkleTreeProof) length() int {
+	return len(p.path)
+}
+
+func (p *arrayMerkleTreeProof) marshal() ([]byte, error) {
+	return arraymt.MarshalPath(p.path), nil
+}
+
+type karyMerkleTree struct {
+	arity int
+	data  []string
+	tree  *kmt.KaryMerkleTree
+}
+
+type karyMerkleTreeProof struct {
+	tr   string
+	root string
+	path []kmt.VerificationNode
+}
+
+func (s *karyMerkleTree) build(data []string) (err error) {
+	s.data = data
+	s.tree, err = kmt.NewKaryMerkleTree(data, s.arity)
+	return err
+}
+
+func (s *karyMerkleTree) prove(pos int) (proof, error) {
+	path, err := s.tree.Prove(pos)
+	return &karyMerkleTreeProof{s.data[pos], s.tree.MerkleRoot(), path}, err
+}
+
+func (s *karyMerkleTree) root() ([]byte, error) {
+	return DecodeHash(s.tree.MerkleRoot())
+}
+
+func (s *karyMerkleTree) leaves() int {
+	return len(s.tree.Leaves)
+}
+
+func (s *karyMerkleTree) depth() int {
+	return s.tree.Root.Depth()
+}
+
+func (p *karyMerkleTreeProof) verify() bool {
+	return kmt.CheckPath(p.tr, p.root, p.path)
+}
+
+func (p *karyMerkleTreeProof) length() int {
+	return len(p.path)
+}
+
+func (p *karyMerkleTreeProof) marshal() ([]byte, error) {
+	return kmt.MarshalPath(p.path)
+}
+
+type hashList struct {
+	data []string
+	list *hashlist.HashList
+}
+
+type hashListProof struct {
+	tr   string
+	head string
+	path []string
+}
+
+func (s *hashList) build(data []string) (err error) {
+	s.data = data
+	s.list, err = hashlist.NewHashList(data)
+	return err
+}
+
+func (s *hashList) prove(pos int) (proof, error) {
+	path, err := s.list.Prove(pos)
+	return &hashListProof{s.data[pos], s.list.HeadHash(), path}, err
+}
+
+// worstPosition is the first element, whose path holds every later element.
+func (s *hashList) worstPosition(size int) int {
+	return 0
+}
+
+func (s *hashList) root() ([]byte, error) {
+	return DecodeHash(s.list.HeadHash())
+}
+
+func (s *hashList) leaves() int {
+	return s.list.Length()
+}
+
+func (p *hashListProof) verify() bool {
+	return hashlist.CheckPath(p.tr, p.head, p.path)
+}
+
+func (p *hashListProof) length() int {
+	return len(p.path)
+}
+
+func (p *hashListProof) marshal() ([]byte, error) {
+	return hashlist.MarshalPath(p.path)
+}
+
+type checkpointHashList struct {
+	interval int
+	data     []string
+	list     *chl.CheckpointHashList
+}
+
+type checkpointHashListProof struct {
+	tr    string
+	head  string
+	proof *chl.Proof
+}
+
+func (s *checkpointHashList) build(data []string) (err error) {
+	s.data = data
+	s.list, err = chl.NewCheckpointHashList(data, s.interval)
+	return err
+}
+
+func (s *checkpointHashList) prove(pos int) (proof, error) {
+	p, err := s.list.Prove(pos)
+	return &checkpointHashListProof{s.data[pos], s.list.HeadHash(), p}, err
+}
+
+// worstPosition is the first element, since the first segment is always full.
+func (s *checkpointHashList) worstPosition(size int) int {
+	return 0
+}
+
+func (s *checkpointHashList) root() ([]byte, error) {
+	return DecodeHash(s.list.HeadHash())
+}
+
+func (s *checkpointHashList) leaves() int {
+	return s.list.Length()
+}
+
+func (p *checkpointHashListProof) verify() bool {
+	return chl.CheckPath(p.tr, p.head, p.proof)
+}
+
+func (p *checkpointHashListProof) length() int {
+	return p.proof.Length()
+}
+
+func (p *checkpointHashListProof) marshal() ([]byte, error) {
+	return p.proof.Marshal()
+}
+
+// skipList expects sorted data since its lookup walks the levels in order.
+type skipList struct {
+	data []string
+	list *asl.SkipList
+}
+
+type skipListProof struct {
+	node  *asl.Node
+	list  *asl.SkipList
+	proof []asl.ProofComponent
+}
+
+func (s *skipList) build(data []string) (err error) {
+	s.data = data
+	s.list, err = asl.NewSkipList(data)
+	return err
+}
+
+func (s *skipList) prove(pos int) (proof, error) {
+	p, node, err := asl.Prove(*s.list, s.data[pos])
+	return &skipListProof{node, s.list, p}, err
+}
+
+func (s *skipList) root() ([]byte, error) {
+	return DecodeHash(s.list.Digest())
+}
+
+func (s *skipList) leaves() int {
+	return s.list.Length()
+}
+
+// depth is the number of linked lists of the skip list.
+func (s *skipList) depth() int {
+	return s.list.Levels()
+}
+
+func (p *skipListProof) verify() bool {
+	return asl.VerifyMembershipProof(*p.node, *p.list, p.proof)
+}
+
+func (p *skipListProof) length() int {
+	return len(p.proof)
+}
+
+func (p *skipListProof) marshal() ([]byte, error) {
+	return asl.MarshalProof(p.proof)
+}
+
+type filterProof struct {
+	tr     string
+	filter filter
+}
+
+func (p *filterProof) verify() bool {
+	return p.filter.test(p.tr)
+}
+
+func (p *filterProof) length() int {
+	return 0
+}
+
+func (p *filterProof) marshal() ([]byte, error) {
+	return nil, nil
+}
+
+type bloomFilter struct {
+	fpr    float64
+	data   []string
+	filter *bloom.BloomFilter
+}
+
+func (s *bloomFilter) build(data []string) (err error) {
+	s.data = data
+	s.filter, err = bloom.NewBloomFilterFromData(data, s.fpr)
+	return err
+}
+
+func (s *bloomFilter) prove(pos int) (proof, error) {
+	return &filterProof{s.data[pos], s}, nil
+}
+
+func (s *bloomFilter) test(tr string) bool {
+	return s.filter.Test(tr)
+}
+
+func (s *bloomFilter) falsePositiveRate() float64 {
+	return s.fpr
+}
+
+type cuckooFilter struct {
+	data   []string
+	filter *cuckoo.CuckooFilter
+}
+
+func (s *cuckooFilter) build(data []string) (err error) {
+	s.data = data
+	s.filter, err = cuckoo.NewCuckooFilterFromData(data)
+	return err
+}
+
+func (s *cuckooFilter) prove(pos int) (proof, error) {
+	return &filterProof{s.data[pos], s}, nil
+}
+
+func (s *cuckooFilter) test(tr string) bool {
+	return s.filter.Test(tr)
+}
+
+func (s *cuckooFilter) falsePositiveRate() float64 {
+	return s.filter.FalsePositiveRate()
+}
+
+type xorFilter struct {
+	data   []string
+	filter *xorfilter.XorFilter
+}
+
+func (s *xorFilter) build(data []string) (err error) {
+	s.data = data
+	s.filter, err = xorfilter.NewXorFilterFromData(data)
+	return err
+}
+
+func (s *xorFilter) prove(pos int) (proof, error) {
+	return &filterProof{s.data[pos], s}, nil
+}
+
+func (s *xorFilter) test(tr string) bool {
+	return s.filter.Test(tr)
+}
+
+func (s *xorFilter) falsePositiveRate() float64 {
+	return s.filter.FalsePositiveRate()
+}
@@ -0,0 +1,219 @@
+package arraymt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+
+	. "github.com/SimoneStefani/thesis-algorithms/structures/common"
+)
+
+// ArrayMerkleTree stores every digest of the tree in a single flat slab,
+// level by level starting from the leaves. Children and siblings are found
+// by index arithmetic instead of following pointers.
+//
+// Example layout with leaves "a"-"e" (5 leaves, 3 + 2 + 1 inner nodes)
+// Level 3:                   [10]
+// Level 2:          [8]              [9]
+// Level 1:     [5]       [6]       [7]
+// Level 0: [0] [1] [2] [3] [4]
+//
+// An odd node at the end of a level is paired with itself, just like the
+// duplicated last node in mt and fastmt.
+type ArrayMerkleTree struct {
+	hashes  []byte
+	offsets []int
+	widths  []int
+}
+
+type VerificationNode struct {
+	hash   []byte
+	isLeft bool
+}
+
+func NewArrayMerkleTree(data []string) (*ArrayMerkleTree, error) {
+	if len(data) == 0 {
+		return nil, errors.New("Error: cannot construct tree with no content.")
+	}
+
+	// count the nodes of every level to allocate the slab only once
+	widths := []int{len(data)}
+	offsets := []int{0}
+	total := len(data)
+	for {
+		w := (widths[len(widths)-1] + 1) / 2
+		offsets = append(offsets, total)
+		widths = append(widths, w)
+		total += w
+		if w == 1 {
+			break
+		}
+	}
+
+	t := &ArrayMerkleTree{
+		hashes:  make([]byte, total*sha256.Size),
+		offsets: offsets,
+		widths:  widths,
+	}
+
+	for i, tr := range data {
+		h := Sum256([]byte(tr))
+		h = Sum256(h[:])
+		copy(t.node(0, i), h[:])
+	}
+
+	var buffer [2 * sha256.Size]byte
+	for level := 1; level < len(widths); level++ {
+		for i := 0; i < widths[level]; i++ {
+			left, right := 2*i, t.sibling(level-1, 2*i)
+			copy(buffer[:sha256.Size], t.node(level-1, left))
+			copy(buffer[sha256.Size:], t.node(level-1, right))
+			h := Sum256(buffer[:])
+			copy(t.node(level, i), h[:])
+		}
+	}
+
+	return t, nil
+}
+
+func VerifyTransaction(tr string, list []string, tree *ArrayMerkleTree) ([]byte, []VerificationNode, bool, error) {
+
+	pos, err := Includes(tr, list)
+
+	if err != nil {
+		return nil, nil, false, err
+	}
+	path := computeMerklePath(pos, tree)
+
+	root := tree.MerkleRoot()
+	return root, path, CheckPath(tr, root, path), err
+}
+
+func CheckPath(tr string, roothash []byte, path []VerificationNode) bool {
+
+	h := Sum256([]byte(tr))
+	h = Sum256(h[:])
+
+	buffer := make([]byte, 2*sha256.Size)
+	for _, node := range path {
+		if node.isLeft {
+			copy(buffer[:sha256.Size], node.hash)
+			copy(buffer[sha256.Size:], h[:])
+		} else {
+			copy(buffer[:sha256.Size], h[:])
+			copy(buffer[sha256.Size:], node.hash)
+		}
+		h = Sum256(buffer)
+	}
+
+	return bytes.Equal(h[:], roothash)
+}
+
+// Prove reads out of the slab the sibling of the leaf at position index and
+// of each of its ancestors, one digest per level below the root.
+func (t *ArrayMerkleTree) Prove(index int) ([]VerificationNode, error) {
+	if index < 0 || index >= t.widths[0] {
+		return nil, errors.New("error: index out of range")
+	}
+
+	return computeMerklePath(index, t), nil
+}
+
+// MarshalPath copies the digests of the path as they are stored in the
+// slab, each after a byte telling whether it is the left child.
+func MarshalPath(path []VerificationNode) []byte {
+	buffer := make([]byte, 0, len(path)*(1+sha256.Size))
+
+	for _, node := range path {
+		side := byte(0)
+		if node.isLeft {
+			side = 1
+		}
+		buffer = append(buffer, side)
+		buffer = append(buffer, node.hash...)
+	}
+
+	return buffer
+}
+
+func UnmarshalPath(data []byte) ([]VerificationNode, error) {
+	if len(data)%(1+sha256.Size) != 0 {
+		return nil, errors.New("error: malformed path")
+	}
+
+	var path []VerificationNode
+	for i := 0; i < len(data); i += 1 + sha256.Size {
+		if data[i] > 1 {
+			return nil, errors.New("error: malformed path")
+		}
+		path = append(path, VerificationNode{
+			hash:   append([]byte(nil), data[i+1:i+1+sha256.Size]...),
+			isLeft: data[i] == 1,
+		})
+	}
+
+	return path, nil
+}
+
+// MerkleRoot returns the digest stored in the last slot of the slab.
+func (t *ArrayMerkleTree) MerkleRoot() []byte {
+	return t.node(len(t.widths)-1, 0)
+}
+
+// Depth counts the levels of the tree, leaves included, so that it matches
+// the result of (*mt.Node).Depth for the same input.
+func (t *ArrayMerkleTree) Depth() int {
+	return len(t.widths)
+}
+
+// Leaves returns the number of leaves of the tree.
+func (t *ArrayMerkleTree) Leaves() int {
+	return t.widths[0]
+}
+
+// Width returns the number of nodes of a level, the leaves being level 0.
+func (t *ArrayMerkleTree) Width(level int) int {
+	return t.widths[level]
+}
+
+// Hash returns the digest of node i at a level, the leaves being level 0.
+func (t *ArrayMerkleTree) Hash(level int, i int) []byte {
+	return t.node(level, i)
+}
+
+// Size returns the number of bytes held by the digest slab.
+func (t *ArrayMerkleTree) Size() int {
+	return len(t.hashes)
+}
+
+func computeMerklePath(pos int, tree *ArrayMerkleTree) []VerificationNode {
+
+	var path []VerificationNode
+	index := pos
+
+	for level := 0; level < len(tree.widths)-1; level++ {
+		path = append(path, VerificationNode{
+			hash:   tree.node(level, tree.sibling(level, index)),
+			isLeft: index%2 == 1,
+		})
+		index = index / 2
+	}
+
+	return path
+}
+
+// node returns the slice of the slab holding the digest of node i at a level.
+func (t *ArrayMerkleTree) node(level int, i int) []byte {
+	start := (t.offsets[level] + i) * sha256.Size
+	return t.hashes[start : start+sha256.Size]
+}
+
+// sibling returns the index of the node paired with i, which is i itself
+// when i is the odd node at the end of the level.
+func (t *ArrayMerkleTree) sibling(level int, i int) int {
+	s := i ^ 1
+	if s >= t.widths[level] {
+		return i
+	}
+	return s
+}
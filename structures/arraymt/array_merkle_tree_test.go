@@ -0,0 +1,125 @@
+package arraymt
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestBuildArrayMerkleTreeWithNoElements(t *testing.T) {
+	_, err := NewArrayMerkleTree([]string{})
+
+	if err == nil {
+		t.Error("Expected error for empty tree")
+	}
+}
+
+func TestBuildArrayMerkleTreeWithSeveralElements(t *testing.T) {
+	mt, _ := NewArrayMerkleTree([]string{"A", "B", "C", "D"})
+	depth := mt.Depth()
+
+	if depth != 3 {
+		t.Error("Expected depth 3, got " + strconv.Itoa(depth))
+	}
+
+	if mt.Size() != 7*32 {
+		t.Error("Expected slab of 224 bytes, got " + strconv.Itoa(mt.Size()))
+	}
+
+	if mt.Leaves() != 4 {
+		t.Error("Expected 4 leaves, got " + strconv.Itoa(mt.Leaves()))
+	}
+}
+
+func TestBuildUnbalancedArrayMerkleTreeWithSeveralElements(t *testing.T) {
+	mt, _ := NewArrayMerkleTree([]string{"A", "B", "C"})
+	depth := mt.Depth()
+
+	if depth != 3 {
+		t.Error("Expected depth 3, got " + strconv.Itoa(depth))
+	}
+}
+
+func TestVerifyArrayMerkleTreeValidTransaction(t *testing.T) {
+	data := []string{"A", "B", "C", "D", "E"}
+	tree, _ := NewArrayMerkleTree(data)
+
+	for _, tr := range data {
+		_, _, result, _ := VerifyTransaction(tr, data, tree)
+
+		if !result {
+			t.Error("Expected true for " + tr + ", got false")
+		}
+	}
+}
+
+func TestVerifyArrayMerkleTreeInvalidTransaction(t *testing.T) {
+	data := []string{"A", "B", "C"}
+	tree, _ := NewArrayMerkleTree(data)
+	_, _, _, err := VerifyTransaction("Z", data, tree)
+
+	if err == nil {
+		t.Error("Invalid verification")
+	}
+}
+
+func TestCheckPathArrayMerkleTreeWrongTransaction(t *testing.T) {
+	data := []string{"A", "B", "C"}
+	tree, _ := NewArrayMerkleTree(data)
+	root, path, _, _ := VerifyTransaction("B", data, tree)
+
+	if CheckPath("Z", root, path) {
+		t.Error("Expected false, got true")
+	}
+}
+
+func TestProveArrayMerkleTreeEveryPosition(t *testing.T) {
+	data := []string{"A", "B", "C", "D", "E"}
+	tree, _ := NewArrayMerkleTree(data)
+
+	// one node per level below the root
+	for i, tr := range data {
+		path, err := tree.Prove(i)
+
+		if err != nil {
+			t.Error("Expected error nil, got " + err.Error())
+		}
+
+		if len(path) != tree.Depth()-1 {
+			t.Error("Expected " + strconv.Itoa(tree.Depth()-1) + " nodes, got " + strconv.Itoa(len(path)))
+		}
+
+		if !CheckPath(tr, tree.MerkleRoot(), path) {
+			t.Error("Expected true for " + tr + ", got false")
+		}
+	}
+
+	if _, err := tree.Prove(-1); err == nil {
+		t.Error("Expected error for index -1")
+	}
+
+	if _, err := tree.Prove(tree.Leaves()); err == nil {
+		t.Error("Expected error for index " + strconv.Itoa(tree.Leaves()))
+	}
+}
+
+func TestMarshalArrayMerkleTreePath(t *testing.T) {
+	tree, _ := NewArrayMerkleTree([]string{"A", "B", "C", "D", "E"})
+	path, _ := tree.Prove(2)
+	data := MarshalPath(path)
+
+	decoded, err := UnmarshalPath(data)
+
+	if err != nil {
+		t.Error("Expected error nil, got " + err.Error())
+	}
+
+	if !CheckPath("C", tree.MerkleRoot(), decoded) {
+		t.Error("Expected true, got false")
+	}
+
+	// the decoded digests don't alias the buffer
+	data[1] ^= 0xff
+	if !CheckPath("C", tree.MerkleRoot(), decoded) {
+		t.Error("Expected true after changing the buffer, got false")
+	}
+}
@@ -1,8 +1,10 @@
 package asl
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"math"
 	"strconv"
 
@@ -75,6 +77,23 @@ func VerifyTransaction(sl SkipList, tr string) (bool, []ProofComponent, *Node, e
 	return verifactionResult, proof, nodePointer, err
 }
 
+// Prove looks up 'tr' and computes its membership proof without verifying it.
+// The returned node holds the index needed by VerifyMembershipProof.
+func Prove(sl SkipList, tr string) ([]ProofComponent, *Node, error) {
+
+	_, nodePointer, exists := Lookup(sl, tr)
+	if !exists {
+		return nil, nil, errors.New("error: not part of skip list")
+	}
+	proof, err := computeMembershipProof(*nodePointer, tr, sl)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return proof, nodePointer, nil
+}
+
 // ProcessMembershipProof (i,n,d,T,E) return true or false.
 // Processes the membership proof E of the membership claim ⟨i, n, d⟩ against authenticator T .
 // In this function 'node' holdes the 'index', and 'datum'
@@ -105,6 +124,145 @@ func VerifyMembershipProof(node Node, sl SkipList, proof []ProofComponent) bool
 	return true
 }
 
+// CheckProof processes the membership proof of 'tr' at position 'index'
+// against the digest of a skip list holding 'length' elements, without the
+// skip list itself. Every component must follow the single hops of
+// computeMembershipProof and the authenticator of the last one must be the
+// digest.
+func CheckProof(tr string, index int, length int, digest string, proof []ProofComponent) bool {
+	if len(proof) == 0 || index < 0 || index >= length || proof[0].tr != tr {
+		return false
+	}
+
+	last := length - 1
+	currentAuth := HashTransaction(HashTransaction(tr))
+	if index > 0 {
+		currentAuth = processProofComponent(index, proof[0])
+	}
+
+	for i := 1; i < len(proof); i++ {
+		if index >= last {
+			return false
+		}
+		level := SingleHopTraversalLevel(index, last-1)
+		index = index + int(math.Pow(2.0, float64(level)))
+		if level >= len(proof[i].authenticator) || proof[i].authenticator[level] != currentAuth {
+			return false
+		}
+		currentAuth = processProofComponent(index, proof[i])
+	}
+
+	return index == last && currentAuth == digest
+}
+
+// MarshalProof encodes a membership proof as the number of components
+// followed by every component: the length of the datum and the datum, then
+// the number of authenticators and their raw hashes. Lengths are varints.
+func MarshalProof(proof []ProofComponent) ([]byte, error) {
+	buffer := binary.AppendUvarint(nil, uint64(len(proof)))
+
+	for _, component := range proof {
+		buffer = binary.AppendUvarint(buffer, uint64(len(component.tr)))
+		buffer = append(buffer, component.tr...)
+		buffer = binary.AppendUvarint(buffer, uint64(len(component.authenticator)))
+		for _, auth := range component.authenticator {
+			raw, err := DecodeHash(auth)
+			if err != nil {
+				return nil, err
+			}
+			buffer = append(buffer, raw...)
+		}
+	}
+
+	return buffer, nil
+}
+
+func UnmarshalProof(data []byte) ([]ProofComponent, error) {
+	malformed := errors.New("error: malformed proof")
+	offset := 0
+
+	next := func() (int, bool) {
+		value, n := binary.Uvarint(data[offset:])
+		if n <= 0 || value > uint64(len(data)) {
+			return 0, false
+		}
+		offset += n
+		return int(value), true
+	}
+
+	count, ok := next()
+	if !ok {
+		return nil, malformed
+	}
+
+	var proof []ProofComponent
+	for i := 0; i < count; i++ {
+		var component ProofComponent
+
+		length, ok := next()
+		if !ok || offset+length > len(data) {
+			return nil, malformed
+		}
+		component.tr = string(data[offset : offset+length])
+		offset += length
+
+		auths, ok := next()
+		if !ok || offset+auths*HashSize > len(data) {
+			return nil, malformed
+		}
+		for j := 0; j < auths; j++ {
+			component.authenticator = append(component.authenticator, EncodeHash(data[offset:offset+HashSize]))
+			offset += HashSize
+		}
+		proof = append(proof, component)
+	}
+	if offset != len(data) {
+		return nil, malformed
+	}
+
+	return proof, nil
+}
+
+// Index returns the position of the node in the base list.
+func (node *Node) Index() int {
+	return node.index
+}
+
+// Transaction returns the datum held by the node.
+func (node *Node) Transaction() string {
+	return node.tr
+}
+
+// Authenticator returns the authenticator of the node at its level.
+func (node *Node) Authenticator() string {
+	return node.auth
+}
+
+// Nodes returns the nodes of the list at 'level', the base list being 0.
+func (sls *SkipList) Nodes(level int) []*Node {
+	var nodes []*Node
+	for node := sls.lists[level].head; node != nil; node = node.next {
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// Digest returns the authenticator of the last element of the base list,
+// which commits to every element of the skip list.
+func (sls *SkipList) Digest() string {
+	return sls.lists[0].tail.auth
+}
+
+// Length returns the number of elements of the skip list.
+func (sls *SkipList) Length() int {
+	return sls.lists[0].length + 1
+}
+
+// Levels returns the number of linked lists stacked in the skip list.
+func (sls *SkipList) Levels() int {
+	return len(sls.lists)
+}
+
 func (sls *SkipList) Lengths() []int {
 	lengths := []int{}
 
@@ -416,76 +574,81 @@ func dummyComputePartialAuthenticator(node Node, level int) string {
 	return "{" + strconv.Itoa(node.index) + "|" + strconv.Itoa(level) + "|" + node.tr + prevAuth + "}"
 }
 
-func PrintList(sl SkipList) {
+// PrintList writes the transactions of every level of the skip list to w.
+func PrintList(w io.Writer, sl SkipList) {
 	for i := len(sl.lists) - 1; i >= 0; i-- {
 		list := sl.lists[i]
 		currentNode := list.head
 		gaps := int(math.Pow(2.0, float64(list.level))) - 1
-		fmt.Printf("Level %d: ", list.level)
+		fmt.Fprintf(w, "Level %d: ", list.level)
 		for {
 			if currentNode == nil {
 				break
 			}
 			if currentNode != list.head {
 				for j := gaps; j > 0; j-- {
-					fmt.Printf("-----")
+					fmt.Fprintf(w, "-----")
 				}
 			}
-			fmt.Printf("-> %s ", currentNode.tr)
+			fmt.Fprintf(w, "-> %s ", currentNode.tr)
 			currentNode = currentNode.next
 		}
-		print("\n")
+		fmt.Fprint(w, "\n")
 	}
-	print("\n")
-	LevelTester(sl)
-	print("\n")
+	fmt.Fprint(w, "\n")
+	LevelTester(w, sl)
+	fmt.Fprint(w, "\n")
 }
 
-func PrintListAuthenticators(sl SkipList) {
+// PrintListAuthenticators writes the truncated authenticators of every
+// level of the skip list to w.
+func PrintListAuthenticators(w io.Writer, sl SkipList) {
 	for i := len(sl.lists) - 1; i >= 0; i-- {
 		list := sl.lists[i]
 		currentNode := list.head
 		gaps := int(math.Pow(2.0, float64(list.level))) - 1
-		fmt.Printf("Level %d: ", list.level)
+		fmt.Fprintf(w, "Level %d: ", list.level)
 		for {
 			if currentNode == nil {
 				break
 			}
 			if currentNode != list.head {
 				for j := gaps; j > 0; j-- {
-					fmt.Printf("---------")
+					fmt.Fprintf(w, "---------")
 				}
 			}
-			fmt.Printf("-> %s ", currentNode.auth[0:5])
+			fmt.Fprintf(w, "-> %s ", currentNode.auth[0:5])
 			currentNode = currentNode.next
 		}
-		fmt.Print("\n")
+		fmt.Fprint(w, "\n")
 	}
 }
 
-func PrintSkipListHeadsAndTails(sl SkipList) {
+// PrintSkipListHeadsAndTails writes the first and last transactions of
+// every level of the skip list to w.
+func PrintSkipListHeadsAndTails(w io.Writer, sl SkipList) {
 	for j := len(sl.lists) - 1; j >= 0; j-- {
 		list := sl.lists[j]
-		fmt.Printf("List %d --> HEAD: %s , TAIL: %s\n", j, list.head.tr, list.tail.tr)
+		fmt.Fprintf(w, "List %d --> HEAD: %s , TAIL: %s\n", j, list.head.tr, list.tail.tr)
 	}
-	fmt.Print("\n")
+	fmt.Fprint(w, "\n")
 }
 
-// Prints the number of levels of each element in a Skip List
-func LevelTester(sl SkipList) {
+// Writes the number of levels of each element in a Skip List to w
+func LevelTester(w io.Writer, sl SkipList) {
 
 	list := sl.lists[0]
 	baseNode := list.head
 	tempNode := baseNode
 	levelCounter := 1
-	fmt.Print("Levels:  -> ")
+	fmt.Fprint(w, "Levels:  -> ")
 	for {
 		if tempNode.up == nil {
 			if baseNode.next == nil {
-				fmt.Printf("%d\n", levelCounter)
+				fmt.Fprintf(w, "%d\n", levelCounter)
 				return
 			}
-			fmt.Printf("%d -> ", levelCounter)
+			fmt.Fprintf(w, "%d -> ", levelCounter)
 			baseNode = baseNode.next
 			tempNode = baseNode
 			levelCounter = 1
@@ -1,8 +1,13 @@
 package asl
 
 import (
+	"bytes"
+	"sort"
 	"strconv"
+	"strings"
 	"testing"
+
+	"github.com/SimoneStefani/thesis-algorithms/structures/internal/benchdata"
 )
 
 func TestBuildSkiplistWithNoElements(t *testing.T) {
@@ -53,3 +58,147 @@ func TestVerifySkiplistInvalidTransaction(t *testing.T) {
 		t.Error("Invalid verification")
 	}
 }
+
+func TestProveSkiplistValidTransaction(t *testing.T) {
+	data := []string{"A", "B", "C", "D", "E"}
+	sl, _ := NewSkipList(data)
+
+	for _, tr := range data {
+		proof, node, err := Prove(*sl, tr)
+
+		if err != nil {
+			t.Error("Expected error nil, got " + err.Error())
+		}
+
+		if !VerifyMembershipProof(*node, *sl, proof) {
+			t.Error("Expected true for " + tr + ", got false")
+		}
+	}
+
+	if _, _, err := Prove(*sl, "Z"); err == nil {
+		t.Error("Invalid proof")
+	}
+}
+
+func TestMarshalSkiplistProof(t *testing.T) {
+	sl, _ := NewSkipList([]string{"A", "B", "C", "D", "E"})
+	proof, node, _ := Prove(*sl, "B")
+	data, err := MarshalProof(proof)
+
+	if err != nil {
+		t.Error("Expected error nil, got " + err.Error())
+	}
+
+	decoded, err := UnmarshalProof(data)
+
+	if err != nil {
+		t.Error("Expected error nil, got " + err.Error())
+	}
+
+	if !VerifyMembershipProof(*node, *sl, decoded) {
+		t.Error("Expected true, got false")
+	}
+
+	if _, err := UnmarshalProof(data[:len(data)-1]); err == nil {
+		t.Error("Expected error for a truncated proof")
+	}
+}
+
+func TestSkiplistDigestCommitsToEveryElement(t *testing.T) {
+	a, _ := NewSkipList([]string{"A", "B", "C", "D", "E", "F"})
+	b, _ := NewSkipList([]string{"A", "B", "C", "D", "E", "G"})
+
+	if a.Digest() == b.Digest() {
+		t.Error("Expected different digests for different last elements")
+	}
+
+	if a.Length() != 6 || a.Levels() != 3 {
+		t.Error("Expected 6 elements in 3 levels, got " + strconv.Itoa(a.Length()) + " in " + strconv.Itoa(a.Levels()))
+	}
+}
+
+func TestCheckSkiplistProofAgainstDigest(t *testing.T) {
+	data := []string{"A", "B", "C", "D", "E", "F", "G", "H", "I", "J", "K"}
+	sl, _ := NewSkipList(data)
+
+	for i, tr := range data {
+		proof, node, _ := Prove(*sl, tr)
+
+		if node.Index() != i {
+			t.Error("Expected index " + strconv.Itoa(i) + ", got " + strconv.Itoa(node.Index()))
+		}
+
+		if !CheckProof(tr, i, sl.Length(), sl.Digest(), proof) {
+			t.Error("Expected true for " + tr + ", got false")
+		}
+
+		if CheckProof("Z", i, sl.Length(), sl.Digest(), proof) {
+			t.Error("Expected false for a different element")
+		}
+	}
+
+	other, _ := NewSkipList([]string{"A", "B", "C", "D", "E", "F", "G", "H", "I", "J", "L"})
+	proof, _, _ := Prove(*sl, "C")
+
+	if CheckProof("C", 2, sl.Length(), other.Digest(), proof) {
+		t.Error("Expected false for the digest of a different skip list")
+	}
+
+	if CheckProof("C", 2, sl.Length(), sl.Digest(), proof[:len(proof)-1]) {
+		t.Error("Expected false for a truncated proof")
+	}
+}
+
+func TestCheckSkiplistProofWithSingleElement(t *testing.T) {
+	sl, _ := NewSkipList([]string{"A"})
+	proof, _, _ := Prove(*sl, "A")
+
+	if !CheckProof("A", 0, 1, sl.Digest(), proof) {
+		t.Error("Expected true, got false")
+	}
+}
+
+func TestPrintSkiplistToWriter(t *testing.T) {
+	sl, _ := NewSkipList([]string{"A", "B", "C", "D", "E", "F"})
+
+	var buffer bytes.Buffer
+	PrintList(&buffer, *sl)
+	lines := strings.Split(buffer.String(), "\n")
+
+	if !strings.HasPrefix(lines[0], "Level 2: ") {
+		t.Error("Expected the top level first, got " + lines[0])
+	}
+
+	if lines[2] != "Level 0: -> A -> B -> C -> D -> E -> F " {
+		t.Error("Expected the base level with every element, got " + lines[2])
+	}
+}
+
+// the lookup of the skip list expects sorted data
+func sortedSkipList(data []string) *SkipList {
+	sort.Strings(data)
+	sl, _ := NewSkipList(data)
+	return sl
+}
+
+func BenchmarkBuild(b *testing.B) {
+	benchdata.Run(b, func(data []string) func() {
+		sort.Strings(data)
+		return func() { NewSkipList(data) }
+	})
+}
+
+func BenchmarkProve(b *testing.B) {
+	benchdata.Run(b, func(data []string) func() {
+		sl := sortedSkipList(data)
+		return func() { Prove(*sl, data[len(data)/2]) }
+	})
+}
+
+func BenchmarkVerify(b *testing.B) {
+	benchdata.Run(b, func(data []string) func() {
+		sl := sortedSkipList(data)
+		proof, node, _ := Prove(*sl, data[len(data)/2])
+		return func() { VerifyMembershipProof(*node, *sl, proof) }
+	})
+}
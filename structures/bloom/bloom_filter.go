@@ -0,0 +1,281 @@
+package bloom
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/bits"
+)
+
+// Flags of the filterload message, see BIP 37. They only matter to full
+// nodes updating the filter while matching transactions.
+const (
+	UpdateNone         byte = 0
+	UpdateAll          byte = 1
+	UpdateP2PubKeyOnly byte = 2
+)
+
+// Limits enforced by BIP 37 nodes on a filterload message. Filters built for
+// the experiments may be larger, in which case Serialize still works but the
+// result would be rejected by the network.
+const (
+	MaxFilterBytes = 36000
+	MaxHashFuncs   = 50
+)
+
+// BloomFilter is a probabilistic set using the hashing scheme of BIP 37:
+// the i-th bit index of an element is MurmurHash3(i * 0xFBA4C795 + tweak)
+// modulo the size of the filter in bits.
+type BloomFilter struct {
+	filter    []byte
+	hashFuncs uint32
+	tweak     uint32
+	flags     byte
+}
+
+// NewBloomFilter creates an empty filter of m bits (rounded up to whole
+// bytes) using k hash functions.
+func NewBloomFilter(m int, k int, tweak uint32, flags byte) (*BloomFilter, error) {
+	if m < 1 || k < 1 {
+		return nil, errors.New("Error: filter size and hash functions must be positive.")
+	}
+
+	bf := &BloomFilter{
+		filter:    make([]byte, (m+7)/8),
+		hashFuncs: uint32(k),
+		tweak:     tweak,
+		flags:     flags,
+	}
+
+	return bf, nil
+}
+
+// NewOptimalBloomFilter creates an empty filter sized for n elements and a
+// target false positive rate p.
+func NewOptimalBloomFilter(n int, p float64, tweak uint32, flags byte) (*BloomFilter, error) {
+	if n < 1 {
+		return nil, errors.New("Error: cannot size a filter for no content.")
+	}
+	if p <= 0 || p >= 1 {
+		return nil, errors.New("Error: false positive rate must be between 0 and 1.")
+	}
+
+	m, k := OptimalSize(n, p)
+	return NewBloomFilter(m, k, tweak, flags)
+}
+
+// NewBloomFilterFromData builds a filter sized for data and the target false
+// positive rate p and adds every transaction to it.
+func NewBloomFilterFromData(data []string, p float64) (*BloomFilter, error) {
+	bf, err := NewOptimalBloomFilter(len(data), p, 0, UpdateNone)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tr := range data {
+		bf.Add(tr)
+	}
+
+	return bf, nil
+}
+
+// OptimalSize returns the number of bits m and hash functions k minimising
+// the false positive rate p for n elements. As in BIP 37 the size is
+// truncated to whole bytes and k is truncated as well.
+func OptimalSize(n int, p float64) (int, int) {
+	bytes := int(-1.0 / (math.Ln2 * math.Ln2) * float64(n) * math.Log(p) / 8)
+	if bytes < 1 {
+		bytes = 1
+	}
+
+	k := int(float64(bytes*8) / float64(n) * math.Ln2)
+	if k < 1 {
+		k = 1
+	}
+
+	return bytes * 8, k
+}
+
+func (bf *BloomFilter) Add(tr string) {
+	for i := uint32(0); i < bf.hashFuncs; i++ {
+		index := bf.bitIndex(i, tr)
+		bf.filter[index>>3] |= 1 << (index & 7)
+	}
+}
+
+// Test returns false if tr was never added to the filter and true if it
+// probably was.
+func (bf *BloomFilter) Test(tr string) bool {
+	for i := uint32(0); i < bf.hashFuncs; i++ {
+		index := bf.bitIndex(i, tr)
+		if bf.filter[index>>3]&(1<<(index&7)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Union adds all the elements of other to the filter. Both filters must have
+// been created with the same size, hash functions and tweak.
+func (bf *BloomFilter) Union(other *BloomFilter) error {
+	if len(bf.filter) != len(other.filter) || bf.hashFuncs != other.hashFuncs || bf.tweak != other.tweak {
+		return errors.New("error: filters are not compatible")
+	}
+
+	for i := range bf.filter {
+		bf.filter[i] |= other.filter[i]
+	}
+
+	return nil
+}
+
+// Bits returns the size of the filter in bits.
+func (bf *BloomFilter) Bits() int {
+	return len(bf.filter) * 8
+}
+
+// HashFuncs returns the number of hash functions of the filter.
+func (bf *BloomFilter) HashFuncs() int {
+	return int(bf.hashFuncs)
+}
+
+// EstimatedFalsePositiveRate returns the expected false positive rate of the
+// filter once n elements have been added.
+func (bf *BloomFilter) EstimatedFalsePositiveRate(n int) float64 {
+	k := float64(bf.hashFuncs)
+	return math.Pow(1-math.Exp(-k*float64(n)/float64(bf.Bits())), k)
+}
+
+// Serialize encodes the filter as the payload of a filterload message:
+// the filter bytes prefixed by their length as a varint, followed by the
+// number of hash functions, the tweak (both little endian) and the flags.
+func (bf *BloomFilter) Serialize() []byte {
+	buffer := make([]byte, 0, binary.MaxVarintLen64+len(bf.filter)+9)
+	buffer = appendVarInt(buffer, uint64(len(bf.filter)))
+	buffer = append(buffer, bf.filter...)
+	buffer = binary.LittleEndian.AppendUint32(buffer, bf.hashFuncs)
+	buffer = binary.LittleEndian.AppendUint32(buffer, bf.tweak)
+	buffer = append(buffer, bf.flags)
+
+	return buffer
+}
+
+// Deserialize decodes the payload of a filterload message.
+func Deserialize(payload []byte) (*BloomFilter, error) {
+	size, offset, err := readVarInt(payload)
+
+	if err != nil {
+		return nil, err
+	}
+	// compare before adding to size, which could overflow
+	if size == 0 || size > MaxFilterBytes || uint64(len(payload)-offset) < 9 || uint64(len(payload)-offset)-9 != size {
+		return nil, errors.New("error: malformed filterload payload")
+	}
+
+	end := offset + int(size)
+	bf := &BloomFilter{
+		filter:    append([]byte(nil), payload[offset:end]...),
+		hashFuncs: binary.LittleEndian.Uint32(payload[end:]),
+		tweak:     binary.LittleEndian.Uint32(payload[end+4:]),
+		flags:     payload[end+8],
+	}
+	// a node would otherwise run up to 2^32 rounds of murmur3 per element
+	if bf.hashFuncs == 0 || bf.hashFuncs > MaxHashFuncs || bf.flags > UpdateP2PubKeyOnly {
+		return nil, errors.New("error: malformed filterload payload")
+	}
+
+	return bf, nil
+}
+
+func (bf *BloomFilter) bitIndex(i uint32, tr string) uint32 {
+	return murmur3(i*0xFBA4C795+bf.tweak, []byte(tr)) % uint32(len(bf.filter)*8)
+}
+
+// appendVarInt appends the Bitcoin CompactSize encoding of v.
+func appendVarInt(buffer []byte, v uint64) []byte {
+	switch {
+	case v < 0xFD:
+		return append(buffer, byte(v))
+	case v <= math.MaxUint16:
+		return binary.LittleEndian.AppendUint16(append(buffer, 0xFD), uint16(v))
+	case v <= math.MaxUint32:
+		return binary.LittleEndian.AppendUint32(append(buffer, 0xFE), uint32(v))
+	default:
+		return binary.LittleEndian.AppendUint64(append(buffer, 0xFF), v)
+	}
+}
+
+// readVarInt decodes a CompactSize and returns it with the bytes it used.
+func readVarInt(buffer []byte) (uint64, int, error) {
+	if len(buffer) == 0 {
+		return 0, 0, errors.New("error: missing varint")
+	}
+
+	size := 1
+	switch buffer[0] {
+	case 0xFD:
+		size = 3
+	case 0xFE:
+		size = 5
+	case 0xFF:
+		size = 9
+	}
+	if len(buffer) < size {
+		return 0, 0, errors.New("error: truncated varint")
+	}
+
+	switch size {
+	case 3:
+		return uint64(binary.LittleEndian.Uint16(buffer[1:])), size, nil
+	case 5:
+		return uint64(binary.LittleEndian.Uint32(buffer[1:])), size, nil
+	case 9:
+		return binary.LittleEndian.Uint64(buffer[1:]), size, nil
+	}
+	return uint64(buffer[0]), size, nil
+}
+
+// murmur3 is the 32-bit MurmurHash3 used by BIP 37.
+func murmur3(seed uint32, data []byte) uint32 {
+	const c1, c2 = 0xcc9e2d51, 0x1b873593
+
+	h := seed
+	blocks := len(data) / 4
+	for i := 0; i < blocks; i++ {
+		k := binary.LittleEndian.Uint32(data[i*4:])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+
+		h ^= k
+		h = bits.RotateLeft32(h, 13)
+		h = h*5 + 0xe6546b64
+	}
+
+	tail := data[blocks*4:]
+	var k uint32
+	switch len(tail) {
+	case 3:
+		k ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(tail[0])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+	}
+
+	h ^= uint32(len(data))
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+
+	return h
+}
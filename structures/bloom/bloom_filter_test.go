@@ -0,0 +1,155 @@
+package bloom
+
+import (
+	"encoding/hex"
+	"strconv"
+	"testing"
+)
+
+func TestMurmurHashVectors(t *testing.T) {
+	vectors := []struct {
+		seed     uint32
+		data     string
+		expected uint32
+	}{
+		{0x00000000, "", 0x00000000},
+		{0xFBA4C795, "", 0x6a396f08},
+		{0xffffffff, "", 0x81f16f39},
+		{0x00000000, "00", 0x514e28b7},
+		{0xFBA4C795, "00", 0xea3f0b17},
+		{0x00000000, "ff", 0xfd6cf10d},
+		{0x00000000, "0011", 0x16c6b7ab},
+		{0x00000000, "001122", 0x8eb51c3d},
+		{0x00000000, "00112233", 0xb4471bf8},
+	}
+
+	for _, v := range vectors {
+		data, _ := hex.DecodeString(v.data)
+		result := murmur3(v.seed, data)
+
+		if result != v.expected {
+			t.Error("Expected " + strconv.FormatUint(uint64(v.expected), 16) + ", got " + strconv.FormatUint(uint64(result), 16))
+		}
+	}
+}
+
+func TestBuildBloomFilterWithInvalidParameters(t *testing.T) {
+	if _, err := NewBloomFilter(0, 3, 0, UpdateNone); err == nil {
+		t.Error("Expected error for empty filter")
+	}
+
+	if _, err := NewOptimalBloomFilter(10, 1.5, 0, UpdateNone); err == nil {
+		t.Error("Expected error for false positive rate 1.5")
+	}
+
+	if _, err := NewBloomFilterFromData([]string{}, 0.01); err == nil {
+		t.Error("Expected error for no content")
+	}
+}
+
+func TestOptimalSize(t *testing.T) {
+	m, k := OptimalSize(3, 0.01)
+
+	if m != 24 || k != 5 {
+		t.Error("Expected 24 bits and 5 hash functions, got " + strconv.Itoa(m) + " and " + strconv.Itoa(k))
+	}
+}
+
+func TestBloomFilterContainsAddedElements(t *testing.T) {
+	data := []string{"A", "B", "C", "D", "E"}
+	bf, _ := NewBloomFilterFromData(data, 0.01)
+
+	for _, tr := range data {
+		if !bf.Test(tr) {
+			t.Error("Expected true for " + tr + ", got false")
+		}
+	}
+}
+
+func TestBloomFilterFalsePositiveRate(t *testing.T) {
+	var data []string
+	for i := 0; i < 1000; i++ {
+		data = append(data, "member-"+strconv.Itoa(i))
+	}
+	bf, _ := NewBloomFilterFromData(data, 0.01)
+
+	positives := 0
+	for i := 0; i < 10000; i++ {
+		if bf.Test("probe-" + strconv.Itoa(i)) {
+			positives++
+		}
+	}
+
+	if positives > 300 {
+		t.Error("Expected about 100 false positives, got " + strconv.Itoa(positives))
+	}
+}
+
+func TestBloomFilterUnion(t *testing.T) {
+	a, _ := NewOptimalBloomFilter(10, 0.01, 0, UpdateNone)
+	b, _ := NewOptimalBloomFilter(10, 0.01, 0, UpdateNone)
+	a.Add("A")
+	b.Add("B")
+
+	if err := a.Union(b); err != nil {
+		t.Error("Expected error nil, got " + err.Error())
+	}
+
+	if !a.Test("A") || !a.Test("B") {
+		t.Error("Expected union to contain A and B")
+	}
+
+	c, _ := NewOptimalBloomFilter(10, 0.01, 1, UpdateNone)
+	if err := a.Union(c); err == nil {
+		t.Error("Expected error for filters with different tweak")
+	}
+}
+
+// Vector from the bloom filter tests of Bitcoin Core
+func TestBloomFilterSerialize(t *testing.T) {
+	bf, _ := NewOptimalBloomFilter(3, 0.01, 0, UpdateAll)
+	for _, tr := range []string{
+		"99108ad8ed9bb6274d3980bab5a85c048f0950c8",
+		"b5a2c786d9ef4658287ced5914b37a1b4aa32eee",
+		"b9300670b4c5366e95b2699e8b18bc75e5f729c5",
+	} {
+		data, _ := hex.DecodeString(tr)
+		bf.Add(string(data))
+	}
+
+	result := hex.EncodeToString(bf.Serialize())
+	if result != "03614e9b050000000000000001" {
+		t.Error("Expected 03614e9b050000000000000001, got " + result)
+	}
+
+	parsed, err := Deserialize(bf.Serialize())
+	if err != nil {
+		t.Error("Expected error nil, got " + err.Error())
+	}
+
+	data, _ := hex.DecodeString("99108ad8ed9bb6274d3980bab5a85c048f0950c8")
+	if !parsed.Test(string(data)) {
+		t.Error("Expected deserialized filter to contain the element")
+	}
+}
+
+func TestDeserializeMalformedPayload(t *testing.T) {
+	for _, payload := range []string{"", "03614e9b", "00050000000000000001", "fff7ffffffffffffff",
+		"01ff330000000000000000", "01ffffffffff0000000000", "01ff010000000000000003"} {
+		data, _ := hex.DecodeString(payload)
+
+		if _, err := Deserialize(data); err == nil {
+			t.Error("Expected error for " + payload)
+		}
+	}
+}
+
+func TestDeserializeOversizedFilter(t *testing.T) {
+	payload := appendVarInt(nil, MaxFilterBytes+1)
+	payload = append(payload, make([]byte, MaxFilterBytes+1)...)
+	payload = append(payload, 1, 0, 0, 0, 0, 0, 0, 0, 0)
+
+	if _, err := Deserialize(payload); err == nil {
+		t.Error("Expected error for a filter larger than MaxFilterBytes")
+	}
+}
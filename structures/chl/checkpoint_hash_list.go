@@ -0,0 +1,252 @@
+package chl
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"strings"
+
+	. "github.com/SimoneStefani/thesis-algorithms/structures/common"
+)
+
+// CheckpointHashList is a hash list whose chain is cut in segments of
+// 'interval' elements. The chain state at the end of every segment is kept
+// as a checkpoint and the head hash commits to the list of checkpoints, so a
+// proof only needs the segment of the element plus the checkpoints.
+//
+// Example CHL with transactions "a"-"g" and interval 3
+// Chain:       a -> b -> c | d -> e -> f | g
+// Checkpoints:          c0 |          c1 | c2
+// Head:        H(c0 + c1 + c2)
+//
+// The chain is the same as the one of hashlist, that is c2 equals the head
+// hash of a hashlist built from the same transactions.
+type CheckpointHashList struct {
+	interval    int
+	leaves      []string
+	checkpoints []string
+	headHash    string
+}
+
+// Proof holds the hashes needed to recompute the checkpoint of the segment
+// containing an element and then the head hash from all the checkpoints.
+type Proof struct {
+	segment     int
+	position    int
+	leaves      []string
+	checkpoints []string
+}
+
+func NewCheckpointHashList(data []string, interval int) (*CheckpointHashList, error) {
+	if len(data) == 0 {
+		return nil, errors.New("Error: cannot construct hashlist with no content.")
+	}
+	if interval < 1 {
+		return nil, errors.New("Error: checkpoint interval must be positive.")
+	}
+
+	cl := &CheckpointHashList{
+		interval: interval,
+		leaves:   make([]string, 0, len(data)),
+	}
+
+	chain := ""
+	for i, tr := range data {
+		leaf := HashTransaction(tr)
+		chain = HashTransaction(leaf + chain)
+		cl.leaves = append(cl.leaves, leaf)
+
+		if (i+1)%interval == 0 || i+1 == len(data) {
+			cl.checkpoints = append(cl.checkpoints, chain)
+		}
+	}
+	cl.headHash = HashTransaction(strings.Join(cl.checkpoints, ""))
+
+	return cl, nil
+}
+
+// OptimalInterval returns the interval that minimises the proof size
+// interval + n/interval for a list of n elements.
+func OptimalInterval(n int) int {
+	interval := int(math.Sqrt(float64(n)))
+	if interval < 1 {
+		return 1
+	}
+	return interval
+}
+
+func VerifyTransaction(tr string, list []string, cl *CheckpointHashList) (string, *Proof, bool, error) {
+
+	pos, err := Includes(tr, list)
+
+	if err != nil {
+		return "", nil, false, err
+	}
+	proof := computeProof(pos, cl)
+
+	return cl.headHash, proof, CheckPath(tr, cl.headHash, proof), nil
+}
+
+func CheckPath(tr string, headHash string, proof *Proof) bool {
+
+	if proof.segment < 0 || proof.segment >= len(proof.checkpoints) {
+		return false
+	}
+	if proof.position < 0 || proof.position > len(proof.leaves) {
+		return false
+	}
+
+	chain := ""
+	if proof.segment > 0 {
+		chain = proof.checkpoints[proof.segment-1]
+	}
+
+	for i := 0; i <= len(proof.leaves); i++ {
+		if i < proof.position {
+			chain = HashTransaction(proof.leaves[i] + chain)
+		} else if i == proof.position {
+			chain = HashTransaction(HashTransaction(tr) + chain)
+		} else {
+			chain = HashTransaction(proof.leaves[i-1] + chain)
+		}
+	}
+
+	if chain != proof.checkpoints[proof.segment] {
+		return false
+	}
+
+	return HashTransaction(strings.Join(proof.checkpoints, "")) == headHash
+}
+
+// Prove returns the proof for the element at position index.
+func (cl *CheckpointHashList) Prove(index int) (*Proof, error) {
+	if index < 0 || index >= len(cl.leaves) {
+		return nil, errors.New("error: index out of range")
+	}
+
+	return computeProof(index, cl), nil
+}
+
+func (cl *CheckpointHashList) HeadHash() string {
+	return cl.headHash
+}
+
+func (cl *CheckpointHashList) Length() int {
+	return len(cl.leaves)
+}
+
+// Interval returns the number of elements between two checkpoints.
+func (cl *CheckpointHashList) Interval() int {
+	return cl.interval
+}
+
+// LeafHashes returns the hashes of the elements, in insertion order.
+func (cl *CheckpointHashList) LeafHashes() []string {
+	return append([]string(nil), cl.leaves...)
+}
+
+// CheckpointHashes returns the chain hashes kept at the end of every segment.
+func (cl *CheckpointHashList) CheckpointHashes() []string {
+	return append([]string(nil), cl.checkpoints...)
+}
+
+// Checkpoints returns the number of checkpoints committed by the head hash.
+func (cl *CheckpointHashList) Checkpoints() int {
+	return len(cl.checkpoints)
+}
+
+// Length returns the number of hashes carried by the proof.
+func (p *Proof) Length() int {
+	return len(p.leaves) + len(p.checkpoints)
+}
+
+// Marshal encodes the proof as the segment and the position as varints
+// followed by the segment hashes and the checkpoints, each one prefixed by
+// its count as a varint.
+func (p *Proof) Marshal() ([]byte, error) {
+	buffer := binary.AppendUvarint(nil, uint64(p.segment))
+	buffer = binary.AppendUvarint(buffer, uint64(p.position))
+
+	for _, hashes := range [][]string{p.leaves, p.checkpoints} {
+		buffer = binary.AppendUvarint(buffer, uint64(len(hashes)))
+		for _, hash := range hashes {
+			raw, err := DecodeHash(hash)
+			if err != nil {
+				return nil, err
+			}
+			buffer = append(buffer, raw...)
+		}
+	}
+
+	return buffer, nil
+}
+
+func UnmarshalProof(data []byte) (*Proof, error) {
+	var values [2]uint64
+	var lists [2][]string
+	offset := 0
+
+	next := func() (uint64, bool) {
+		value, n := binary.Uvarint(data[offset:])
+		if n <= 0 {
+			return 0, false
+		}
+		offset += n
+		return value, true
+	}
+
+	for i := 0; i < 2; i++ {
+		value, ok := next()
+		if !ok {
+			return nil, errors.New("error: malformed proof")
+		}
+		values[i] = value
+	}
+	for i := range lists {
+		count, ok := next()
+		if !ok || count > uint64((len(data)-offset)/HashSize) {
+			return nil, errors.New("error: malformed proof")
+		}
+		for j := uint64(0); j < count; j++ {
+			lists[i] = append(lists[i], EncodeHash(data[offset:offset+HashSize]))
+			offset += HashSize
+		}
+	}
+	if offset != len(data) {
+		return nil, errors.New("error: malformed proof")
+	}
+	// the element is in one of the segments and at most after all the other
+	// hashes of its segment
+	if values[0] >= uint64(len(lists[1])) || values[1] > uint64(len(lists[0])) {
+		return nil, errors.New("error: malformed proof")
+	}
+
+	proof := &Proof{
+		segment:     int(values[0]),
+		position:    int(values[1]),
+		leaves:      lists[0],
+		checkpoints: lists[1],
+	}
+
+	return proof, nil
+}
+
+func computeProof(pos int, cl *CheckpointHashList) *Proof {
+
+	segment := pos / cl.interval
+	start := segment * cl.interval
+	end := int(math.Min(float64(start+cl.interval), float64(len(cl.leaves))))
+
+	proof := &Proof{
+		segment:     segment,
+		position:    pos - start,
+		checkpoints: cl.checkpoints,
+	}
+	for i := start; i < end; i++ {
+		if i != pos {
+			proof.leaves = append(proof.leaves, cl.leaves[i])
+		}
+	}
+
+	return proof
+}
@@ -0,0 +1,141 @@
+package chl
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestBuildCheckpointHashListWithNoElements(t *testing.T) {
+	_, err := NewCheckpointHashList([]string{}, 2)
+
+	if err == nil {
+		t.Error("Expected error for empty list")
+	}
+}
+
+func TestBuildCheckpointHashListWithInvalidInterval(t *testing.T) {
+	_, err := NewCheckpointHashList([]string{"A"}, 0)
+
+	if err == nil {
+		t.Error("Expected error for interval 0")
+	}
+}
+
+func TestBuildCheckpointHashListWithSeveralElements(t *testing.T) {
+	cl, _ := NewCheckpointHashList([]string{"A", "B", "C", "D", "E", "F", "G"}, 3)
+
+	if cl.Length() != 7 {
+		t.Error("Expected length 7, got " + strconv.Itoa(cl.Length()))
+	}
+
+	if cl.Checkpoints() != 3 {
+		t.Error("Expected 3 checkpoints, got " + strconv.Itoa(cl.Checkpoints()))
+	}
+}
+
+func TestVerifyCheckpointHashListValidTransaction(t *testing.T) {
+	data := []string{"A", "B", "C", "D", "E", "F", "G"}
+	cl, _ := NewCheckpointHashList(data, 3)
+
+	for _, tr := range data {
+		_, proof, result, _ := VerifyTransaction(tr, data, cl)
+
+		if !result {
+			t.Error("Expected true for " + tr + ", got false")
+		}
+
+		if proof.Length() > 3+cl.Checkpoints() {
+			t.Error("Expected proof bounded by interval and checkpoints, got " + strconv.Itoa(proof.Length()))
+		}
+	}
+}
+
+func TestVerifyCheckpointHashListInvalidTransaction(t *testing.T) {
+	data := []string{"A", "B", "C"}
+	cl, _ := NewCheckpointHashList(data, 2)
+	_, _, _, err := VerifyTransaction("Z", data, cl)
+
+	if err == nil {
+		t.Error("Invalid verification")
+	}
+}
+
+func TestCheckPathCheckpointHashListWrongTransaction(t *testing.T) {
+	data := []string{"A", "B", "C", "D"}
+	cl, _ := NewCheckpointHashList(data, 2)
+	head, proof, _, _ := VerifyTransaction("C", data, cl)
+
+	if CheckPath("Z", head, proof) {
+		t.Error("Expected false, got true")
+	}
+}
+
+func TestProveCheckpointHashListEveryPosition(t *testing.T) {
+	data := []string{"A", "B", "C", "D", "E"}
+	cl, _ := NewCheckpointHashList(data, 2)
+
+	for i, tr := range data {
+		proof, err := cl.Prove(i)
+
+		if err != nil {
+			t.Error("Expected error nil, got " + err.Error())
+		}
+
+		if !CheckPath(tr, cl.HeadHash(), proof) {
+			t.Error("Expected true for " + tr + ", got false")
+		}
+	}
+
+	if _, err := cl.Prove(5); err == nil {
+		t.Error("Expected error for index 5")
+	}
+}
+
+func TestMarshalCheckpointHashListProof(t *testing.T) {
+	cl, _ := NewCheckpointHashList([]string{"A", "B", "C", "D", "E"}, 2)
+	proof, _ := cl.Prove(3)
+	data, err := proof.Marshal()
+
+	if err != nil {
+		t.Error("Expected error nil, got " + err.Error())
+	}
+
+	decoded, err := UnmarshalProof(data)
+
+	if err != nil {
+		t.Error("Expected error nil, got " + err.Error())
+	}
+
+	if !CheckPath("D", cl.HeadHash(), decoded) {
+		t.Error("Expected true, got false")
+	}
+
+	if _, err := UnmarshalProof(data[:len(data)-1]); err == nil {
+		t.Error("Expected error for a truncated proof")
+	}
+}
+
+func TestCheckPathCheckpointHashListPositionOutOfRange(t *testing.T) {
+	cl, _ := NewCheckpointHashList([]string{"A"}, 2)
+	proof := &Proof{segment: 0, position: 5, checkpoints: cl.CheckpointHashes()}
+
+	if CheckPath("A", cl.HeadHash(), proof) {
+		t.Error("Expected false for position 5, got true")
+	}
+}
+
+func TestUnmarshalCheckpointHashListProofOutOfRange(t *testing.T) {
+	cl, _ := NewCheckpointHashList([]string{"A", "B", "C"}, 2)
+	proof, _ := cl.Prove(0)
+	data, _ := proof.Marshal()
+
+	// a segment past the checkpoints, then a position past the leaves
+	segment := append([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, data[1:]...)
+	position := append([]byte{data[0], 0x05}, data[2:]...)
+
+	for _, crafted := range [][]byte{segment, position} {
+		if _, err := UnmarshalProof(crafted); err == nil {
+			t.Error("Expected error for a proof out of range")
+		}
+	}
+}
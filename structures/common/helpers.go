@@ -4,13 +4,69 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"errors"
+	"sync/atomic"
 )
 
+// HashSize is the number of bytes of a digest once decoded from base64.
+const HashSize = sha256.Size
+
+// hashCount counts the SHA-256 invocations, see HashCount.
+var hashCount uint64
+
 func HashTransaction(tr string) string {
+	atomic.AddUint64(&hashCount, 1)
 	h := sha256.Sum256([]byte(tr))
 	return base64.StdEncoding.EncodeToString(h[:])
 }
 
+// Sum256 is sha256.Sum256 for the structures working on raw digests, counted
+// like HashTransaction.
+func Sum256(data []byte) [HashSize]byte {
+	atomic.AddUint64(&hashCount, 1)
+	return sha256.Sum256(data)
+}
+
+// HashCount returns the number of SHA-256 invocations since the program
+// started. The difference of two calls gives the hashes computed in between.
+func HashCount() uint64 {
+	return atomic.LoadUint64(&hashCount)
+}
+
+// DecodeHash returns the raw bytes of a digest returned by HashTransaction.
+func DecodeHash(hash string) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(hash)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) != HashSize {
+		return nil, errors.New("error: invalid hash length")
+	}
+	return raw, nil
+}
+
+// EncodeHash is the inverse of DecodeHash.
+func EncodeHash(raw []byte) string {
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+// HashTransaction64 returns a 64-bit digest of tr (FNV-1a followed by the
+// MurmurHash3 finaliser) for the structures that work on integer keys.
+func HashTransaction64(tr string) uint64 {
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(tr); i++ {
+		h ^= uint64(tr[i])
+		h *= 1099511628211
+	}
+
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+
+	return h
+}
+
 func Includes(tr string, list []string) (int, error) {
 
 	for i, transaction := range list {
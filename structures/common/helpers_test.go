@@ -27,6 +27,43 @@ func TestHashTransactionWithSampleString(t *testing.T) {
 	}
 }
 
+func TestHashTransaction64IsDeterministic(t *testing.T) {
+	if HashTransaction64("A") != HashTransaction64("A") {
+		t.Error("Expected equal digests for the same transaction")
+	}
+
+	if HashTransaction64("A") == HashTransaction64("B") {
+		t.Error("Expected different digests for different transactions")
+	}
+}
+
+func TestHashCountCountsInvocations(t *testing.T) {
+	before := HashCount()
+	HashTransaction("A")
+	Sum256([]byte("A"))
+
+	if HashCount()-before != 2 {
+		t.Error("Expected 2 hashes, got " + strconv.FormatUint(HashCount()-before, 10))
+	}
+}
+
+func TestDecodeHashRoundTrip(t *testing.T) {
+	hash := HashTransaction("A")
+	raw, err := DecodeHash(hash)
+
+	if err != nil {
+		t.Error("Expected error nil, got " + err.Error())
+	}
+
+	if len(raw) != HashSize || EncodeHash(raw) != hash {
+		t.Error("Expected " + hash + ", got " + EncodeHash(raw))
+	}
+
+	if _, err := DecodeHash("QQ=="); err == nil {
+		t.Error("Expected error for a short hash")
+	}
+}
+
 func TestIncludesTransactionIsSuccessful(t *testing.T) {
 	transactionsSet := []string{"A", "B", "C", "D"}
 	transaction := "C"
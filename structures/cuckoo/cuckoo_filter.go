@@ -0,0 +1,189 @@
+package cuckoo
+
+import (
+	"errors"
+	"math/rand"
+
+	. "github.com/SimoneStefani/thesis-algorithms/structures/common"
+)
+
+const (
+	// bucketSize is the number of fingerprints stored in a bucket
+	bucketSize = 4
+	// maxKicks bounds the relocations tried before an insertion fails
+	maxKicks = 500
+	// loadFactor is the occupancy the filter is sized for, below the 95%
+	// reachable with 4 slots per bucket so that maxKicks rarely runs out
+	loadFactor = 0.9
+)
+
+// CuckooFilter is a probabilistic set storing a 16-bit fingerprint of every
+// element in one of two candidate buckets (partial-key cuckoo hashing).
+// Unlike a Bloom filter it supports deleting elements.
+//
+// The alternate bucket only depends on the current bucket and the
+// fingerprint, so fingerprints can be moved without the original element:
+// i2 = i1 xor hash(fingerprint)
+type CuckooFilter struct {
+	buckets [][bucketSize]uint16
+	mask    uint64
+	count   int
+	rng     *rand.Rand
+}
+
+// NewCuckooFilter creates an empty filter able to hold capacity elements.
+func NewCuckooFilter(capacity int) (*CuckooFilter, error) {
+	if capacity < 1 {
+		return nil, errors.New("Error: cannot size a filter for no content.")
+	}
+
+	buckets := 1
+	for float64(buckets*bucketSize)*loadFactor < float64(capacity) {
+		buckets = buckets << 1
+	}
+
+	cf := &CuckooFilter{
+		buckets: make([][bucketSize]uint16, buckets),
+		mask:    uint64(buckets - 1),
+		rng:     rand.New(rand.NewSource(int64(capacity))),
+	}
+
+	return cf, nil
+}
+
+// NewCuckooFilterFromData builds a filter sized for data and adds every
+// distinct transaction to it once, see Add for why copies are skipped.
+func NewCuckooFilterFromData(data []string) (*CuckooFilter, error) {
+	cf, err := NewCuckooFilter(len(data))
+
+	if err != nil {
+		return nil, err
+	}
+
+	added := make(map[string]bool, len(data))
+	for _, tr := range data {
+		if added[tr] {
+			continue
+		}
+		added[tr] = true
+
+		if err := cf.Add(tr); err != nil {
+			return nil, err
+		}
+	}
+
+	return cf, nil
+}
+
+// Add inserts tr, moving other fingerprints around if both of its buckets
+// are full. It fails when no free slot is found after maxKicks moves, in
+// which case the moves are undone and the filter is left as it was. Every
+// copy of an element takes a slot of the same two buckets, so at most
+// 2*bucketSize copies of one element can be added.
+func (cf *CuckooFilter) Add(tr string) error {
+	fp, i1, i2 := cf.locate(tr)
+
+	if cf.insert(i1, fp) || cf.insert(i2, fp) {
+		cf.count++
+		return nil
+	}
+
+	i := i1
+	if cf.rng.Intn(2) == 1 {
+		i = i2
+	}
+	var buckets [maxKicks]uint64
+	var slots [maxKicks]int
+	for kick := 0; kick < maxKicks; kick++ {
+		slot := cf.rng.Intn(bucketSize)
+		fp, cf.buckets[i][slot] = cf.buckets[i][slot], fp
+		buckets[kick], slots[kick] = i, slot
+		i = cf.alternate(i, fp)
+		if cf.insert(i, fp) {
+			cf.count++
+			return nil
+		}
+	}
+
+	// put the evicted fingerprints back rather than dropping the last one,
+	// which would make its element a false negative
+	for kick := maxKicks - 1; kick >= 0; kick-- {
+		i, slot := buckets[kick], slots[kick]
+		fp, cf.buckets[i][slot] = cf.buckets[i][slot], fp
+	}
+
+	return errors.New("error: cuckoo filter is full")
+}
+
+// Test returns false if tr is not in the filter and true if it probably is.
+func (cf *CuckooFilter) Test(tr string) bool {
+	fp, i1, i2 := cf.locate(tr)
+	return cf.find(i1, fp) >= 0 || cf.find(i2, fp) >= 0
+}
+
+// Delete removes one copy of tr and reports whether it was found. Deleting
+// an element that was never added may remove a colliding one.
+func (cf *CuckooFilter) Delete(tr string) bool {
+	fp, i1, i2 := cf.locate(tr)
+
+	for _, i := range []uint64{i1, i2} {
+		if slot := cf.find(i, fp); slot >= 0 {
+			cf.buckets[i][slot] = 0
+			cf.count--
+			return true
+		}
+	}
+
+	return false
+}
+
+// Count returns the number of fingerprints stored in the filter.
+func (cf *CuckooFilter) Count() int {
+	return cf.count
+}
+
+// Size returns the number of bytes used by the buckets.
+func (cf *CuckooFilter) Size() int {
+	return len(cf.buckets) * bucketSize * 2
+}
+
+// FalsePositiveRate returns the upper bound 2b/2^f of the false positive
+// rate for buckets of b entries and f-bit fingerprints.
+func (cf *CuckooFilter) FalsePositiveRate() float64 {
+	return 2 * bucketSize / 65535.0
+}
+
+// locate returns the fingerprint of tr, which is never zero since zero marks
+// an empty slot, and its two candidate buckets.
+func (cf *CuckooFilter) locate(tr string) (uint16, uint64, uint64) {
+	h := HashTransaction64(tr)
+
+	fp := uint16(h>>32)%65535 + 1
+	i1 := h & cf.mask
+
+	return fp, i1, cf.alternate(i1, fp)
+}
+
+func (cf *CuckooFilter) alternate(i uint64, fp uint16) uint64 {
+	// multiply by the MurmurHash2 constant to spread the fingerprint bits
+	return (i ^ uint64(fp)*0x5bd1e995) & cf.mask
+}
+
+func (cf *CuckooFilter) insert(i uint64, fp uint16) bool {
+	for slot, entry := range cf.buckets[i] {
+		if entry == 0 {
+			cf.buckets[i][slot] = fp
+			return true
+		}
+	}
+	return false
+}
+
+func (cf *CuckooFilter) find(i uint64, fp uint16) int {
+	for slot, entry := range cf.buckets[i] {
+		if entry == fp {
+			return slot
+		}
+	}
+	return -1
+}
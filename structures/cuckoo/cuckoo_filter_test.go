@@ -0,0 +1,135 @@
+package cuckoo
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func TestBuildCuckooFilterWithNoElements(t *testing.T) {
+	_, err := NewCuckooFilterFromData([]string{})
+
+	if err == nil {
+		t.Error("Expected error for empty filter")
+	}
+}
+
+func TestCuckooFilterContainsAddedElements(t *testing.T) {
+	var data []string
+	for i := 0; i < 10000; i++ {
+		data = append(data, "member-"+strconv.Itoa(i))
+	}
+	cf, err := NewCuckooFilterFromData(data)
+
+	if err != nil {
+		t.Fatal("Expected error nil, got " + err.Error())
+	}
+
+	if cf.Count() != len(data) {
+		t.Error("Expected 10000 elements, got " + strconv.Itoa(cf.Count()))
+	}
+
+	for _, tr := range data {
+		if !cf.Test(tr) {
+			t.Error("Expected true for " + tr + ", got false")
+		}
+	}
+}
+
+func TestCuckooFilterFalsePositiveRate(t *testing.T) {
+	var data []string
+	for i := 0; i < 10000; i++ {
+		data = append(data, "member-"+strconv.Itoa(i))
+	}
+	cf, _ := NewCuckooFilterFromData(data)
+
+	positives := 0
+	for i := 0; i < 100000; i++ {
+		if cf.Test("probe-" + strconv.Itoa(i)) {
+			positives++
+		}
+	}
+
+	if positives > 100 {
+		t.Error("Expected about 12 false positives, got " + strconv.Itoa(positives))
+	}
+}
+
+func TestCuckooFilterDelete(t *testing.T) {
+	cf, _ := NewCuckooFilterFromData([]string{"A", "B", "C"})
+
+	if !cf.Delete("B") {
+		t.Error("Expected true, got false")
+	}
+
+	if cf.Test("B") {
+		t.Error("Expected B to be deleted")
+	}
+
+	if !cf.Test("A") || !cf.Test("C") {
+		t.Error("Expected A and C to be kept")
+	}
+
+	if cf.Count() != 2 {
+		t.Error("Expected 2 elements, got " + strconv.Itoa(cf.Count()))
+	}
+}
+
+func TestCuckooFilterFullKeepsElements(t *testing.T) {
+	cf, _ := NewCuckooFilter(1)
+	data := []string{"A", "B", "C", "D"}
+
+	for _, tr := range data {
+		if err := cf.Add(tr); err != nil {
+			t.Error("Expected error nil, got " + err.Error())
+		}
+	}
+
+	for i := 0; i < 10; i++ {
+		if err := cf.Add("E" + strconv.Itoa(i)); err == nil {
+			t.Error("Expected error for a full filter")
+		}
+	}
+
+	for _, tr := range data {
+		if !cf.Test(tr) {
+			t.Error("Expected true for " + tr + " after a failed insertion, got false")
+		}
+	}
+}
+
+func TestBuildCuckooFilterWithDuplicates(t *testing.T) {
+	data := []string{"B"}
+	for i := 0; i < 20; i++ {
+		data = append(data, "A")
+	}
+
+	cf, err := NewCuckooFilterFromData(data)
+
+	if err != nil {
+		t.Fatal("Expected error nil, got " + err.Error())
+	}
+
+	if cf.Count() != 2 || !cf.Test("A") || !cf.Test("B") {
+		t.Error("Expected A and B once, got " + strconv.Itoa(cf.Count()) + " elements")
+	}
+}
+
+func TestBuildCuckooFilterNearBucketBoundaries(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	// the largest inputs before the table doubles are the fullest
+	for _, buckets := range []int{256, 1024, 4096} {
+		n := int(float64(buckets*bucketSize) * loadFactor)
+		for _, size := range []int{n - 1, n} {
+			var data []string
+			for i := 0; i < size; i++ {
+				data = append(data, strconv.FormatUint(rng.Uint64(), 36))
+			}
+
+			if _, err := NewCuckooFilterFromData(data); err != nil {
+				t.Error("Expected error nil for " + strconv.Itoa(size) + " elements, got " + err.Error())
+			}
+		}
+	}
+}
@@ -11,6 +11,9 @@ type FastMerkleTree struct {
 	Root       *Node
 	merkleRoot string
 	Leaves     []*Node
+
+	// the number of transactions, without the duplicate of an odd last leaf
+	length int
 }
 
 type Node struct {
@@ -37,6 +40,7 @@ func NewFastMerkleTree(data []string) (*FastMerkleTree, error) {
 		Root:       root,
 		merkleRoot: root.hash,
 		Leaves:     leaves,
+		length:     len(data),
 	}
 
 	return t, nil
@@ -70,6 +74,70 @@ func CheckPath(tr string, roothash string, path []VerificationNode) bool {
 	return hash == roothash
 }
 
+// Prove follows the parents of the leaf at position index and collects the
+// sibling of every node on the way, nearest first.
+func (t *FastMerkleTree) Prove(index int) ([]VerificationNode, error) {
+	if index < 0 || index >= t.length {
+		return nil, errors.New("error: index out of range")
+	}
+
+	return computeMerklePath(index, t), nil
+}
+
+func (t *FastMerkleTree) MerkleRoot() string {
+	return t.merkleRoot
+}
+
+// MarshalPath writes the same layout as mt.MarshalPath; only the way the
+// hashes are chained by CheckPath differs between the two trees.
+func MarshalPath(path []VerificationNode) ([]byte, error) {
+	buffer := make([]byte, 0, len(path)*(1+HashSize))
+
+	for _, node := range path {
+		raw, err := DecodeHash(node.hash)
+		if err != nil {
+			return nil, err
+		}
+		side := byte(0)
+		if node.isLeft {
+			side = 1
+		}
+		buffer = append(buffer, side)
+		buffer = append(buffer, raw...)
+	}
+
+	return buffer, nil
+}
+
+func UnmarshalPath(data []byte) ([]VerificationNode, error) {
+	if len(data)%(1+HashSize) != 0 {
+		return nil, errors.New("error: malformed path")
+	}
+
+	var path []VerificationNode
+	for i := 0; i < len(data); i += 1 + HashSize {
+		if data[i] > 1 {
+			return nil, errors.New("error: malformed path")
+		}
+		path = append(path, VerificationNode{
+			hash:   EncodeHash(data[i+1 : i+1+HashSize]),
+			isLeft: data[i] == 1,
+		})
+	}
+
+	return path, nil
+}
+
+// Hash returns the digest of the node.
+func (node *Node) Hash() string {
+	return node.hash
+}
+
+// Data returns the transaction of a leaf, empty for inner nodes.
+func (node *Node) Data() string {
+	return node.data
+}
+
 func (root *Node) Depth() int {
 	if root == nil {
 		return 0
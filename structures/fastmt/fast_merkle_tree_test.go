@@ -3,6 +3,8 @@ package fastmt
 import (
 	"strconv"
 	"testing"
+
+	"github.com/SimoneStefani/thesis-algorithms/structures/internal/benchdata"
 )
 
 func TestBuildFastMerkleTreeWithNoElements(t *testing.T) {
@@ -50,3 +52,68 @@ func TestVerifyFastMerkleTreeInvalidTransaction(t *testing.T) {
 		t.Error("Invalid verification")
 	}
 }
+
+func TestProveFastMerkleTreeEveryPosition(t *testing.T) {
+	// six leaves make three nodes above them, the last one paired with itself
+	data := []string{"A", "B", "C", "D", "E", "F"}
+	tree, _ := NewFastMerkleTree(data)
+
+	for i, tr := range data {
+		path, err := tree.Prove(i)
+
+		if err != nil {
+			t.Error("Expected error nil, got " + err.Error())
+		}
+
+		if len(path) != 3 || !CheckPath(tr, tree.MerkleRoot(), path) {
+			t.Error("Expected a valid path of 3 nodes for " + tr + ", got " + strconv.Itoa(len(path)))
+		}
+	}
+
+	if _, err := tree.Prove(-1); err == nil {
+		t.Error("Expected error for index -1")
+	}
+
+	if _, err := tree.Prove(6); err == nil {
+		t.Error("Expected error for index 6")
+	}
+}
+
+func TestUnmarshalFastMerkleTreePathSideByte(t *testing.T) {
+	tree, _ := NewFastMerkleTree([]string{"A", "B", "C", "D", "E"})
+	path, _ := tree.Prove(2)
+	data, _ := MarshalPath(path)
+
+	// C is a left child, its first sibling is on the right
+	data[0] = 1
+	decoded, _ := UnmarshalPath(data)
+	if CheckPath("C", tree.MerkleRoot(), decoded) {
+		t.Error("Expected false for a swapped sibling, got true")
+	}
+
+	data[0] = 2
+	if _, err := UnmarshalPath(data); err == nil {
+		t.Error("Expected error for side byte 2")
+	}
+}
+
+func BenchmarkBuild(b *testing.B) {
+	benchdata.Run(b, func(data []string) func() {
+		return func() { NewFastMerkleTree(data) }
+	})
+}
+
+func BenchmarkProve(b *testing.B) {
+	benchdata.Run(b, func(data []string) func() {
+		tree, _ := NewFastMerkleTree(data)
+		return func() { tree.Prove(len(data) / 2) }
+	})
+}
+
+func BenchmarkVerify(b *testing.B) {
+	benchdata.Run(b, func(data []string) func() {
+		tree, _ := NewFastMerkleTree(data)
+		path, _ := tree.Prove(len(data) / 2)
+		return func() { CheckPath(data[len(data)/2], tree.MerkleRoot(), path) }
+	})
+}
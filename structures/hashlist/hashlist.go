@@ -10,6 +10,7 @@ type Node struct {
 	prev *Node
 	next *Node
 	tr   string
+	leaf string
 }
 
 type List struct {
@@ -33,14 +34,18 @@ func NewHashList(data []string) (*HashList, error) {
 	return hashList, nil
 }
 
-func VerifyTransaction(tr string, list []string) (string, []string, bool, error) {
+func VerifyTransaction(tr string, list []string, hl *HashList) (string, []string, bool, error) {
 
 	pos, err := Includes(tr, list)
 
 	if err != nil {
 		return "", nil, false, err
 	}
-	path, hl := computePath(pos, list)
+	path, err := hl.Prove(pos)
+
+	if err != nil {
+		return "", nil, false, err
+	}
 
 	return hl.headHash, path, CheckPath(tr, hl.headHash, path), nil
 }
@@ -61,6 +66,38 @@ func CheckPath(tr string, headHash string, path []string) bool {
 	return hash == headHash
 }
 
+// MarshalPath encodes a path as the raw bytes of its hashes.
+func MarshalPath(path []string) ([]byte, error) {
+	buffer := make([]byte, 0, len(path)*HashSize)
+
+	for _, hash := range path {
+		raw, err := DecodeHash(hash)
+		if err != nil {
+			return nil, err
+		}
+		buffer = append(buffer, raw...)
+	}
+
+	return buffer, nil
+}
+
+func UnmarshalPath(data []byte) ([]string, error) {
+	if len(data) == 0 || len(data)%HashSize != 0 {
+		return nil, errors.New("error: malformed path")
+	}
+
+	var path []string
+	for i := 0; i < len(data); i += HashSize {
+		path = append(path, EncodeHash(data[i:i+HashSize]))
+	}
+
+	return path, nil
+}
+
+func (hl *HashList) HeadHash() string {
+	return hl.headHash
+}
+
 func (hl *HashList) Length() int {
 	if hl.list.head == hl.list.tail {
 		return 1
@@ -79,29 +116,46 @@ func (hl *HashList) Length() int {
 	return count
 }
 
-func computePath(pos int, list []string) ([]string, *HashList) {
+// Chain returns the chain hashes of the list, from the one of the first
+// element to the head hash.
+func (hl *HashList) Chain() []string {
+	var chain []string
+	for current := hl.list.tail; current != nil; current = current.prev {
+		chain = append(chain, current.tr)
+	}
+	return chain
+}
 
-	var path []string
-	temp := &List{
-		head: nil,
-		tail: nil,
+// Prove walks the stored list from the first element and collects the path
+// for the element at position index. The first hash of the path is the
+// chain hash preceding the element (or the element's own one if it is the
+// first), followed by the hashes of all the elements inserted after it.
+func (hl *HashList) Prove(index int) ([]string, error) {
+
+	if index < 0 {
+		return nil, errors.New("error: index out of range")
 	}
 
-	for i, tr := range list {
-		temp = insert(*temp, tr)
-		if pos < i {
-			path = append(path, HashTransaction(tr))
-		} else if pos == i+1 || pos == 0 {
-			path = append(path, temp.head.tr)
+	var path []string
+	i := 0
+	current := hl.list.tail
+	for current != nil {
+		if index == 0 && i == 0 {
+			path = append(path, current.tr)
+		} else if i+1 == index {
+			path = append(path, current.tr)
+		} else if i > index {
+			path = append(path, current.leaf)
 		}
+		current = current.prev
+		i = i + 1
 	}
 
-	hl := &HashList{
-		headHash: temp.head.tr,
-		list:     temp,
+	if index >= i {
+		return nil, errors.New("error: index out of range")
 	}
 
-	return path, hl
+	return path, nil
 }
 
 func buildHashList(data []string) (*HashList, error) {
@@ -130,18 +184,22 @@ func buildHashList(data []string) (*HashList, error) {
 func insert(list List, tr string) *List {
 
 	if list.head == nil {
+		leaf := HashTransaction(tr)
 		new := &Node{
 			prev: nil,
 			next: nil,
-			tr:   HashTransaction(HashTransaction(tr)),
+			tr:   HashTransaction(leaf),
+			leaf: leaf,
 		}
 		list.head = new
 		list.tail = new
 	} else {
+		leaf := HashTransaction(tr)
 		new := &Node{
 			next: list.head,
 			prev: nil,
-			tr:   HashTransaction(HashTransaction(tr) + list.head.tr),
+			tr:   HashTransaction(leaf + list.head.tr),
+			leaf: leaf,
 		}
 		list.head = new
 		new.next.prev = new
@@ -3,6 +3,8 @@ package hashlist
 import (
 	"strconv"
 	"testing"
+
+	"github.com/SimoneStefani/thesis-algorithms/structures/internal/benchdata"
 )
 
 func TestBuildHashlistWithNoElements(t *testing.T) {
@@ -33,7 +35,8 @@ func TestBuildHashlistWithSeveralElements(t *testing.T) {
 
 func TestVerifyHashlistValidTransaction(t *testing.T) {
 	data := []string{"A", "B", "C"}
-	_, _, result, _ := VerifyTransaction("B", data)
+	hl, _ := NewHashList(data)
+	_, _, result, _ := VerifyTransaction("B", data, hl)
 
 	if !result {
 		t.Error("Expected true, got false")
@@ -42,7 +45,8 @@ func TestVerifyHashlistValidTransaction(t *testing.T) {
 
 func TestVerifyHashlistValidTransactionInFirstElement(t *testing.T) {
 	data := []string{"A", "B", "C"}
-	_, _, result, _ := VerifyTransaction("A", data)
+	hl, _ := NewHashList(data)
+	_, _, result, _ := VerifyTransaction("A", data, hl)
 
 	if !result {
 		t.Error("Expected true, got false")
@@ -51,9 +55,93 @@ func TestVerifyHashlistValidTransactionInFirstElement(t *testing.T) {
 
 func TestVerifyHashlistInvalidTransaction(t *testing.T) {
 	data := []string{"A", "B", "C"}
-	_, _, _, err := VerifyTransaction("Z", data)
+	hl, _ := NewHashList(data)
+	_, _, _, err := VerifyTransaction("Z", data, hl)
 
 	if err == nil {
 		t.Error("Invalid verification")
 	}
 }
+
+func TestProveHashlistEveryPosition(t *testing.T) {
+	data := []string{"A", "B", "C", "D", "E"}
+	hl, _ := NewHashList(data)
+
+	for i, tr := range data {
+		path, err := hl.Prove(i)
+
+		if err != nil {
+			t.Error("Expected error nil, got " + err.Error())
+		}
+
+		if !CheckPath(tr, hl.headHash, path) {
+			t.Error("Expected true for " + tr + ", got false")
+		}
+
+		// the preceding chain hash and one hash per later element, so the
+		// path shortens towards the head
+		expected := len(data) - i
+		if i == 0 {
+			expected = len(data)
+		}
+		if len(path) != expected {
+			t.Error("Expected " + strconv.Itoa(expected) + " hashes for " + tr + ", got " + strconv.Itoa(len(path)))
+		}
+	}
+}
+
+func TestProveHashlistOutOfRange(t *testing.T) {
+	hl, _ := NewHashList([]string{"A", "B", "C"})
+
+	if _, err := hl.Prove(3); err == nil {
+		t.Error("Expected error for index 3")
+	}
+
+	if _, err := hl.Prove(-1); err == nil {
+		t.Error("Expected error for index -1")
+	}
+}
+
+func TestMarshalHashlistPath(t *testing.T) {
+	hl, _ := NewHashList([]string{"A", "B", "C", "D"})
+	path, _ := hl.Prove(1)
+	data, err := MarshalPath(path)
+
+	if err != nil {
+		t.Error("Expected error nil, got " + err.Error())
+	}
+
+	if len(data) != len(path)*32 {
+		t.Error("Expected " + strconv.Itoa(len(path)*32) + " bytes, got " + strconv.Itoa(len(data)))
+	}
+
+	decoded, _ := UnmarshalPath(data)
+	if !CheckPath("B", hl.HeadHash(), decoded) {
+		t.Error("Expected true, got false")
+	}
+
+	if _, err := UnmarshalPath(nil); err == nil {
+		t.Error("Expected error for an empty path")
+	}
+}
+
+func BenchmarkBuild(b *testing.B) {
+	benchdata.Run(b, func(data []string) func() {
+		return func() { NewHashList(data) }
+	})
+}
+
+func BenchmarkProve(b *testing.B) {
+	benchdata.Run(b, func(data []string) func() {
+		hl, _ := NewHashList(data)
+		return func() { hl.Prove(len(data) / 2) }
+	})
+}
+
+func BenchmarkVerify(b *testing.B) {
+	benchdata.Run(b, func(data []string) func() {
+		hl, _ := NewHashList(data)
+		path, _ := hl.Prove(len(data) / 2)
+		return func() { CheckPath(data[len(data)/2], hl.HeadHash(), path) }
+	})
+}
@@ -0,0 +1,42 @@
+// Package benchdata runs the benchmarks of the structures over the same
+// inputs.
+package benchdata
+
+import (
+	"strconv"
+	"testing"
+)
+
+// Sizes are the numbers of transactions the structures are benchmarked with.
+var Sizes = []int{10, 100, 1000, 10000, 100000, 1000000}
+
+// Transactions returns n distinct transactions.
+func Transactions(n int) []string {
+	data := make([]string, n)
+	for i := range data {
+		data[i] = "transaction-" + strconv.Itoa(i)
+	}
+	return data
+}
+
+// Run runs one sub-benchmark n=<size> per size, timing the function
+// returned by setup for the transactions of that size. The transactions and
+// the setup are only built for the sizes selected with -bench, once each,
+// and outside the timer.
+func Run(b *testing.B, setup func(data []string) func()) {
+	for _, n := range Sizes {
+		n := n
+		var op func()
+
+		b.Run("n="+strconv.Itoa(n), func(b *testing.B) {
+			if op == nil {
+				op = setup(Transactions(n))
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				op()
+			}
+		})
+	}
+}
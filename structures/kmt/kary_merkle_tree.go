@@ -0,0 +1,252 @@
+package kmt
+
+import (
+	"errors"
+	"strings"
+
+	. "github.com/SimoneStefani/thesis-algorithms/structures/common"
+)
+
+const (
+	MinArity = 2
+	MaxArity = 16
+)
+
+// KaryMerkleTree generalises fastmt to nodes with up to 'arity' children.
+// The last group of a level is filled up by repeating its last node, which
+// for arity 2 is the same duplication done by mt and fastmt.
+//
+// Example KMT with transactions "a"-"e" and arity 3
+// Level 2:              r
+// Level 1:      n0              n1
+// Level 0:  a   b   c       d   e   e
+type KaryMerkleTree struct {
+	Root       *Node
+	merkleRoot string
+	arity      int
+	Leaves     []*Node
+}
+
+type Node struct {
+	Parent   *Node
+	Children []*Node
+	position int
+	hash     string
+	data     string
+}
+
+// VerificationNode is a single step of a proof: the hashes of the other
+// children of the parent, in order, and the position of the node among them.
+type VerificationNode struct {
+	siblings []string
+	position int
+}
+
+func NewKaryMerkleTree(data []string, arity int) (*KaryMerkleTree, error) {
+	if arity < MinArity || arity > MaxArity {
+		return nil, errors.New("Error: arity must be between 2 and 16.")
+	}
+
+	root, leaves, err := buildWithContent(data, arity)
+
+	if err != nil {
+		return nil, err
+	}
+
+	t := &KaryMerkleTree{
+		Root:       root,
+		merkleRoot: root.hash,
+		arity:      arity,
+		Leaves:     leaves,
+	}
+
+	return t, nil
+}
+
+func VerifyTransaction(tr string, list []string, tree *KaryMerkleTree) (string, []VerificationNode, bool, error) {
+
+	pos, err := Includes(tr, list)
+
+	if err != nil {
+		return "", nil, false, err
+	}
+
+	path := computeMerklePath(pos, tree)
+
+	return tree.merkleRoot, path, CheckPath(tr, tree.merkleRoot, path), err
+}
+
+func CheckPath(tr string, roothash string, path []VerificationNode) bool {
+
+	hash := HashTransaction(HashTransaction(tr))
+
+	for _, node := range path {
+		if node.position < 0 || node.position > len(node.siblings) {
+			return false
+		}
+		children := make([]string, 0, len(node.siblings)+1)
+		children = append(children, node.siblings[:node.position]...)
+		children = append(children, hash)
+		children = append(children, node.siblings[node.position:]...)
+		hash = HashTransaction(strings.Join(children, ""))
+	}
+
+	return hash == roothash
+}
+
+// MarshalPath encodes a path as, for every node, one byte with the position
+// of the node and one with the number of siblings followed by their raw
+// hashes.
+func MarshalPath(path []VerificationNode) ([]byte, error) {
+	var buffer []byte
+
+	for _, node := range path {
+		buffer = append(buffer, byte(node.position), byte(len(node.siblings)))
+		for _, sibling := range node.siblings {
+			raw, err := DecodeHash(sibling)
+			if err != nil {
+				return nil, err
+			}
+			buffer = append(buffer, raw...)
+		}
+	}
+
+	return buffer, nil
+}
+
+func UnmarshalPath(data []byte) ([]VerificationNode, error) {
+	var path []VerificationNode
+
+	for i := 0; i < len(data); {
+		if i+2 > len(data) {
+			return nil, errors.New("error: malformed path")
+		}
+		node := VerificationNode{position: int(data[i])}
+		count := int(data[i+1])
+		i += 2
+		if count >= MaxArity || i+count*HashSize > len(data) {
+			return nil, errors.New("error: malformed path")
+		}
+		for j := 0; j < count; j++ {
+			node.siblings = append(node.siblings, EncodeHash(data[i:i+HashSize]))
+			i += HashSize
+		}
+		path = append(path, node)
+	}
+
+	return path, nil
+}
+
+func (t *KaryMerkleTree) Arity() int {
+	return t.arity
+}
+
+// Prove returns, for the leaf at position index and each of its ancestors,
+// its position among the children of its parent and the hashes of the others.
+func (t *KaryMerkleTree) Prove(index int) ([]VerificationNode, error) {
+	if index < 0 || index >= len(t.Leaves) {
+		return nil, errors.New("error: index out of range")
+	}
+
+	return computeMerklePath(index, t), nil
+}
+
+func (t *KaryMerkleTree) MerkleRoot() string {
+	return t.merkleRoot
+}
+
+// Hash returns the digest of the node.
+func (node *Node) Hash() string {
+	return node.hash
+}
+
+// Data returns the transaction of a leaf, empty for inner nodes.
+func (node *Node) Data() string {
+	return node.data
+}
+
+func (root *Node) Depth() int {
+	if root == nil {
+		return 0
+	}
+
+	bigger := 0
+	for _, child := range root.Children {
+		if depth := child.Depth(); depth > bigger {
+			bigger = depth
+		}
+	}
+
+	return bigger + 1
+}
+
+func computeMerklePath(pos int, tree *KaryMerkleTree) []VerificationNode {
+
+	node := tree.Leaves[pos]
+
+	var path []VerificationNode
+
+	for {
+		if node.Parent == nil {
+			break
+		}
+		temp := VerificationNode{
+			position: node.position,
+		}
+		for i, child := range node.Parent.Children {
+			if i != node.position {
+				temp.siblings = append(temp.siblings, child.hash)
+			}
+		}
+		path = append(path, temp)
+		node = node.Parent
+	}
+
+	return path
+}
+
+func buildWithContent(data []string, arity int) (*Node, []*Node, error) {
+	if len(data) == 0 {
+		return nil, nil, errors.New("Error: cannot construct tree with no content.")
+	}
+
+	var leaves []*Node
+	for _, tr := range data {
+		leaves = append(leaves, &Node{
+			hash: HashTransaction(HashTransaction(tr)),
+			data: tr,
+		})
+	}
+
+	root := buildIntermediate(leaves, arity)
+	return root, leaves, nil
+}
+
+func buildIntermediate(nl []*Node, arity int) *Node {
+	var nodes []*Node
+
+	for i := 0; i < len(nl); i += arity {
+
+		n := &Node{}
+		buffer := ""
+		for j := 0; j < arity; j++ {
+			// repeat the last node of the level to fill the group
+			child := nl[len(nl)-1]
+			if i+j < len(nl) {
+				child = nl[i+j]
+				child.Parent = n
+				child.position = j
+			}
+			n.Children = append(n.Children, child)
+			buffer = buffer + child.hash
+		}
+		n.hash = HashTransaction(buffer)
+		nodes = append(nodes, n)
+	}
+
+	if len(nodes) == 1 {
+		return nodes[0]
+	}
+
+	return buildIntermediate(nodes, arity)
+}
@@ -0,0 +1,146 @@
+package kmt
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/SimoneStefani/thesis-algorithms/structures/fastmt"
+)
+
+func TestBuildKaryMerkleTreeWithNoElements(t *testing.T) {
+	_, err := NewKaryMerkleTree([]string{}, 4)
+
+	if err == nil {
+		t.Error("Expected error for empty tree")
+	}
+}
+
+func TestBuildKaryMerkleTreeWithInvalidArity(t *testing.T) {
+	for _, arity := range []int{0, 1, 17} {
+		_, err := NewKaryMerkleTree([]string{"A", "B"}, arity)
+
+		if err == nil {
+			t.Error("Expected error for arity " + strconv.Itoa(arity))
+		}
+	}
+}
+
+func TestBuildKaryMerkleTreeWithSeveralElements(t *testing.T) {
+	mt, _ := NewKaryMerkleTree([]string{"A", "B", "C", "D", "E"}, 3)
+	depth := mt.Root.Depth()
+
+	if depth != 3 {
+		t.Error("Expected depth 3, got " + strconv.Itoa(depth))
+	}
+
+	mt, _ = NewKaryMerkleTree([]string{"A", "B", "C", "D", "E"}, 8)
+	depth = mt.Root.Depth()
+
+	if depth != 2 {
+		t.Error("Expected depth 2, got " + strconv.Itoa(depth))
+	}
+}
+
+func TestBinaryKaryMerkleTreeMatchesFastMerkleTree(t *testing.T) {
+	data := []string{"A", "B", "C", "D", "E"}
+	tree, _ := NewKaryMerkleTree(data, 2)
+	fast, _ := fastmt.NewFastMerkleTree(data)
+	root, _, _, _ := fastmt.VerifyTransaction("A", data, fast)
+
+	if tree.merkleRoot != root {
+		t.Error("Expected " + root + ", got " + tree.merkleRoot)
+	}
+}
+
+func TestVerifyKaryMerkleTreeValidTransaction(t *testing.T) {
+	data := []string{"A", "B", "C", "D", "E", "F", "G"}
+
+	for arity := MinArity; arity <= MaxArity; arity++ {
+		tree, _ := NewKaryMerkleTree(data, arity)
+
+		for _, tr := range data {
+			_, path, result, _ := VerifyTransaction(tr, data, tree)
+
+			if !result {
+				t.Error("Expected true for " + tr + " with arity " + strconv.Itoa(arity) + ", got false")
+			}
+
+			for _, node := range path {
+				if len(node.siblings) != arity-1 {
+					t.Error("Expected " + strconv.Itoa(arity-1) + " siblings, got " + strconv.Itoa(len(node.siblings)))
+				}
+			}
+		}
+	}
+}
+
+func TestVerifyKaryMerkleTreeInvalidTransaction(t *testing.T) {
+	data := []string{"A", "B", "C"}
+	tree, _ := NewKaryMerkleTree(data, 4)
+	_, _, _, err := VerifyTransaction("Z", data, tree)
+
+	if err == nil {
+		t.Error("Invalid verification")
+	}
+}
+
+func TestProveKaryMerkleTreeEveryPosition(t *testing.T) {
+	data := []string{"A", "B", "C", "D", "E"}
+	tree, _ := NewKaryMerkleTree(data, 3)
+
+	for i, tr := range data {
+		path, err := tree.Prove(i)
+
+		if err != nil {
+			t.Error("Expected error nil, got " + err.Error())
+		}
+
+		if !CheckPath(tr, tree.MerkleRoot(), path) {
+			t.Error("Expected true for " + tr + ", got false")
+		}
+	}
+
+	// E is the second child of D, E, E: the repeated E is one of its siblings
+	path, _ := tree.Prove(4)
+	if path[0].position != 1 || len(path[0].siblings) != 2 || path[0].siblings[1] != tree.Leaves[4].hash {
+		t.Error("Expected position 1 with siblings D and E, got position " + strconv.Itoa(path[0].position))
+	}
+
+	if _, err := tree.Prove(-1); err == nil {
+		t.Error("Expected error for index -1")
+	}
+
+	if _, err := tree.Prove(5); err == nil {
+		t.Error("Expected error for index 5")
+	}
+}
+
+func TestMarshalKaryMerkleTreePath(t *testing.T) {
+	tree, _ := NewKaryMerkleTree([]string{"A", "B", "C", "D", "E"}, 3)
+	path, _ := tree.Prove(2)
+	data, err := MarshalPath(path)
+
+	if err != nil {
+		t.Error("Expected error nil, got " + err.Error())
+	}
+
+	decoded, err := UnmarshalPath(data)
+
+	if err != nil {
+		t.Error("Expected error nil, got " + err.Error())
+	}
+
+	if !CheckPath("C", tree.MerkleRoot(), decoded) {
+		t.Error("Expected true, got false")
+	}
+
+	// two levels of two siblings each, after their position and count bytes
+	if len(data) != 2*(2+2*32) {
+		t.Error("Expected " + strconv.Itoa(2*(2+2*32)) + " bytes, got " + strconv.Itoa(len(data)))
+	}
+
+	data[1] = MaxArity
+	if _, err := UnmarshalPath(data); err == nil {
+		t.Error("Expected error for " + strconv.Itoa(MaxArity) + " siblings")
+	}
+}
@@ -0,0 +1,299 @@
+package mt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+
+	. "github.com/SimoneStefani/thesis-algorithms/structures/common"
+)
+
+// BitcoinHasher hashes like the Merkle trees of Bitcoin blocks: a leaf is a
+// txid as its raw 32 bytes, in the byte order it was hashed in, and an inner
+// node the double SHA-256 of the raw digests of its children.
+var BitcoinHasher = Hasher{
+	Leaf: func(txid string) string {
+		return EncodeHash([]byte(txid))
+	},
+	Combine: func(left string, right string) string {
+		l, _ := DecodeHash(left)
+		r, _ := DecodeHash(right)
+		return EncodeHash(sha256d(append(l, r...)))
+	},
+}
+
+func sha256d(data []byte) []byte {
+	first := Sum256(data)
+	second := Sum256(first[:])
+	return second[:]
+}
+
+// NewBitcoinTree builds the Merkle tree of the txids of a block with
+// BitcoinHasher. Its root is the merkle root of the block header: the last
+// node of a level is paired with itself, and a single txid is the root on
+// its own.
+func NewBitcoinTree(txids [][]byte) (*MerkleTree, error) {
+	data := make([]string, len(txids))
+	for i, txid := range txids {
+		if len(txid) != HashSize {
+			return nil, errors.New("error: a txid is 32 bytes")
+		}
+		data[i] = string(txid)
+	}
+
+	t, err := NewTreeWithHasher(data, BitcoinHasher)
+	if err != nil {
+		return nil, err
+	}
+	t.transactions = len(txids)
+
+	if len(txids) == 1 {
+		leaf := t.Leaves[0]
+		leaf.Parent = nil
+		t.Root, t.merkleRoot, t.Leaves = leaf, leaf.hash, t.Leaves[:1]
+	}
+	return t, nil
+}
+
+// RawRoot returns the raw bytes of the root, as in a block header for a
+// tree built by NewBitcoinTree.
+func (t *MerkleTree) RawRoot() []byte {
+	raw, _ := DecodeHash(t.merkleRoot)
+	return raw
+}
+
+// levels returns the digests of the tree of a block level by level from the
+// txids, without the duplicated last nodes.
+func (t *MerkleTree) levels() [][][]byte {
+	var level []*Node
+	for _, leaf := range t.Leaves[:t.transactions] {
+		level = append(level, leaf)
+	}
+
+	var levels [][][]byte
+	for {
+		var hashes [][]byte
+		var parents []*Node
+		for _, node := range level {
+			raw, _ := DecodeHash(node.hash)
+			hashes = append(hashes, raw)
+			if node.Parent != nil && (len(parents) == 0 || parents[len(parents)-1] != node.Parent) {
+				parents = append(parents, node.Parent)
+			}
+		}
+		levels = append(levels, hashes)
+		if len(parents) == 0 {
+			return levels
+		}
+		level = parents
+	}
+}
+
+// PartialMerkleTree returns the proof that the txids at the given indices
+// are part of a tree built by NewBitcoinTree, serialized like in the
+// merkleblock messages of BIP 37 after the header: the number of
+// transactions as 4 bytes little endian, the hashes and the flag bits.
+func (t *MerkleTree) PartialMerkleTree(indices ...int) ([]byte, error) {
+	if t.transactions == 0 {
+		return nil, errors.New("error: not a bitcoin tree")
+	}
+
+	matches := make([]bool, t.transactions)
+	for _, index := range indices {
+		if index < 0 || index >= t.transactions {
+			return nil, errors.New("error: index out of range")
+		}
+		matches[index] = true
+	}
+
+	p := &partialTree{transactions: t.transactions}
+	levels := t.levels()
+	p.build(len(levels)-1, 0, levels, matches)
+	return p.marshal(), nil
+}
+
+// CheckPartialMerkleTree extracts the txids proven by a partial Merkle tree
+// and their indices, and returns the root the tree commits to. The proof is
+// valid when the root is the one of the block header.
+func CheckPartialMerkleTree(data []byte) ([]byte, [][]byte, []int, error) {
+	p, err := unmarshalPartialTree(data)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	height := 0
+	for p.width(height) > 1 {
+		height++
+	}
+
+	var txids [][]byte
+	var indices []int
+	root := p.extract(height, 0, &txids, &indices)
+	if p.err != nil {
+		return nil, nil, nil, p.err
+	}
+
+	// every hash and every flag bit but the padding of the last byte is used
+	if p.hashIndex != len(p.hashes) || (p.bitIndex+7)/8 != len(p.flags) {
+		return nil, nil, nil, errors.New("error: unused hashes or flags in partial merkle tree")
+	}
+	return root, txids, indices, nil
+}
+
+// partialTree is the depth first traversal of the tree of a block which
+// descends only into the subtrees of matched txids. A flag bit set means
+// that the node has a matched txid below it.
+type partialTree struct {
+	transactions int
+	hashes       [][]byte
+	flags        []byte
+	bits         int
+
+	hashIndex int
+	bitIndex  int
+	err       error
+}
+
+// width returns the number of nodes at a height of the tree, the txids
+// being at height 0.
+func (p *partialTree) width(height int) int {
+	return (p.transactions + (1 << uint(height)) - 1) >> uint(height)
+}
+
+func (p *partialTree) pushBit(bit bool) {
+	if p.bits%8 == 0 {
+		p.flags = append(p.flags, 0)
+	}
+	if bit {
+		p.flags[p.bits/8] |= 1 << uint(p.bits%8)
+	}
+	p.bits++
+}
+
+func (p *partialTree) build(height int, pos int, levels [][][]byte, matches []bool) {
+	parentOfMatch := false
+	for i := pos << uint(height); i < (pos+1)<<uint(height) && i < p.transactions; i++ {
+		parentOfMatch = parentOfMatch || matches[i]
+	}
+	p.pushBit(parentOfMatch)
+
+	if height == 0 || !parentOfMatch {
+		p.hashes = append(p.hashes, levels[height][pos])
+		return
+	}
+	p.build(height-1, pos*2, levels, matches)
+	if pos*2+1 < p.width(height-1) {
+		p.build(height-1, pos*2+1, levels, matches)
+	}
+}
+
+func (p *partialTree) extract(height int, pos int, txids *[][]byte, indices *[]int) []byte {
+	if p.err != nil {
+		return nil
+	}
+	if p.bitIndex >= len(p.flags)*8 {
+		p.err = errors.New("error: truncated flags in partial merkle tree")
+		return nil
+	}
+	parentOfMatch := p.flags[p.bitIndex/8]&(1<<uint(p.bitIndex%8)) != 0
+	p.bitIndex++
+
+	if height == 0 || !parentOfMatch {
+		if p.hashIndex >= len(p.hashes) {
+			p.err = errors.New("error: truncated hashes in partial merkle tree")
+			return nil
+		}
+		hash := p.hashes[p.hashIndex]
+		p.hashIndex++
+		if height == 0 && parentOfMatch {
+			*txids = append(*txids, hash)
+			*indices = append(*indices, pos)
+		}
+		return hash
+	}
+
+	left := p.extract(height-1, pos*2, txids, indices)
+	right := left
+	if pos*2+1 < p.width(height-1) {
+		right = p.extract(height-1, pos*2+1, txids, indices)
+		// identical children would let a forged tree hide a duplicated
+		// transaction (CVE-2012-2459)
+		if p.err == nil && bytes.Equal(left, right) {
+			p.err = errors.New("error: identical children in partial merkle tree")
+		}
+	}
+	if p.err != nil {
+		return nil
+	}
+	return sha256d(append(append([]byte(nil), left...), right...))
+}
+
+func (p *partialTree) marshal() []byte {
+	buffer := binary.LittleEndian.AppendUint32(nil, uint32(p.transactions))
+	buffer = appendCompactSize(buffer, uint64(len(p.hashes)))
+	for _, hash := range p.hashes {
+		buffer = append(buffer, hash...)
+	}
+	buffer = appendCompactSize(buffer, uint64(len(p.flags)))
+	return append(buffer, p.flags...)
+}
+
+func unmarshalPartialTree(data []byte) (*partialTree, error) {
+	malformed := errors.New("error: malformed partial merkle tree")
+	if len(data) < 4 {
+		return nil, malformed
+	}
+	p := &partialTree{transactions: int(binary.LittleEndian.Uint32(data))}
+	data = data[4:]
+	if p.transactions == 0 {
+		return nil, malformed
+	}
+
+	n, data, ok := readCompactSize(data)
+	if !ok || n > uint64(len(data)/HashSize) || n > uint64(p.transactions) {
+		return nil, malformed
+	}
+	for i := uint64(0); i < n; i++ {
+		p.hashes = append(p.hashes, data[:HashSize])
+		data = data[HashSize:]
+	}
+
+	n, data, ok = readCompactSize(data)
+	if !ok || n != uint64(len(data)) {
+		return nil, malformed
+	}
+	p.flags = data
+	return p, nil
+}
+
+// appendCompactSize appends the variable length integers of the Bitcoin
+// serialization.
+func appendCompactSize(buffer []byte, n uint64) []byte {
+	switch {
+	case n < 0xfd:
+		return append(buffer, byte(n))
+	case n <= 0xffff:
+		return binary.LittleEndian.AppendUint16(append(buffer, 0xfd), uint16(n))
+	case n <= 0xffffffff:
+		return binary.LittleEndian.AppendUint32(append(buffer, 0xfe), uint32(n))
+	}
+	return binary.LittleEndian.AppendUint64(append(buffer, 0xff), n)
+}
+
+func readCompactSize(data []byte) (uint64, []byte, bool) {
+	if len(data) == 0 {
+		return 0, nil, false
+	}
+
+	size := map[byte]int{0xfd: 2, 0xfe: 4, 0xff: 8}[data[0]]
+	if size == 0 {
+		return uint64(data[0]), data[1:], true
+	}
+	if len(data) < 1+size {
+		return 0, nil, false
+	}
+
+	var buf [8]byte
+	copy(buf[:], data[1:1+size])
+	return binary.LittleEndian.Uint64(buf[:]), data[1+size:], true
+}
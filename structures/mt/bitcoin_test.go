@@ -0,0 +1,137 @@
+package mt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strconv"
+	"testing"
+)
+
+// The txids of block 100000 as displayed, in reversed byte order.
+var block100000 = []string{
+	"8c14f0db3df150123e6f3dbbf30f8b955a8249b62ac1d1ff16284aefa3d06d87",
+	"fff2525b8931402dd09222c50775608f75787bd2b87e56995a7bdd30f79702c4",
+	"6359f0868171b1d194cbee1af2f16ea598ae8fad666d9b012c8ed2b79a236ec4",
+	"e9a66845e05d5abc0ad04ec80f774a7e585c6e8db975962d069a522137b80c1d",
+}
+
+func reversed(raw []byte) []byte {
+	out := make([]byte, len(raw))
+	for i, b := range raw {
+		out[len(raw)-1-i] = b
+	}
+	return out
+}
+
+func displayedTxIDs(hashes []string) [][]byte {
+	var txids [][]byte
+	for _, hash := range hashes {
+		raw, _ := hex.DecodeString(hash)
+		txids = append(txids, reversed(raw))
+	}
+	return txids
+}
+
+func testTxIDs(n int) [][]byte {
+	var txids [][]byte
+	for i := 0; i < n; i++ {
+		txids = append(txids, sha256d([]byte(strconv.Itoa(i))))
+	}
+	return txids
+}
+
+func TestBitcoinTreeOfBlock100000(t *testing.T) {
+	tree, err := NewBitcoinTree(displayedTxIDs(block100000))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := hex.EncodeToString(reversed(tree.RawRoot()))
+	if root != "f3e94742aca4b5ef85488dc37c06c3282295ffec960994b2c0d5ac2a25a95766" {
+		t.Error("Expected the merkle root of block 100000, got " + root)
+	}
+}
+
+func TestBitcoinTreeWithSingleTxID(t *testing.T) {
+	txids := testTxIDs(1)
+	tree, _ := NewBitcoinTree(txids)
+
+	if !bytes.Equal(tree.RawRoot(), txids[0]) {
+		t.Error("Expected the txid to be the root")
+	}
+}
+
+func TestBitcoinTreeRejectsShortTxIDs(t *testing.T) {
+	_, err := NewBitcoinTree([][]byte{[]byte("short")})
+
+	if err == nil {
+		t.Error("Expected error for a txid of 5 bytes")
+	}
+}
+
+func TestPartialMerkleTreeEveryIndex(t *testing.T) {
+	for n := 1; n <= 40; n++ {
+		txids := testTxIDs(n)
+		tree, _ := NewBitcoinTree(txids)
+
+		for i := 0; i < n; i++ {
+			proof, err := tree.PartialMerkleTree(i, n-1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			root, matched, indices, err := CheckPartialMerkleTree(proof)
+			if err != nil {
+				t.Fatal("n=" + strconv.Itoa(n) + " i=" + strconv.Itoa(i) + ": " + err.Error())
+			}
+
+			if !bytes.Equal(root, tree.RawRoot()) {
+				t.Error("Expected the root of the tree for n=" + strconv.Itoa(n) + " i=" + strconv.Itoa(i))
+			}
+
+			if indices[0] != i || !bytes.Equal(matched[0], txids[i]) || indices[len(indices)-1] != n-1 {
+				t.Error("Expected the txids at " + strconv.Itoa(i) + " and " + strconv.Itoa(n-1))
+			}
+		}
+	}
+}
+
+func TestPartialMerkleTreeWithoutMatches(t *testing.T) {
+	tree, _ := NewBitcoinTree(testTxIDs(7))
+
+	proof, _ := tree.PartialMerkleTree()
+	root, matched, _, err := CheckPartialMerkleTree(proof)
+
+	if err != nil || !bytes.Equal(root, tree.RawRoot()) || len(matched) != 0 {
+		t.Error("Expected the root alone without matches")
+	}
+}
+
+func TestPartialMerkleTreeTampered(t *testing.T) {
+	tree, _ := NewBitcoinTree(testTxIDs(9))
+	proof, _ := tree.PartialMerkleTree(4)
+
+	// the first hash starts after the count and the number of hashes
+	tampered := append([]byte(nil), proof...)
+	tampered[5] ^= 1
+	root, _, _, err := CheckPartialMerkleTree(tampered)
+	if err == nil && bytes.Equal(root, tree.RawRoot()) {
+		t.Error("Expected a different root for a tampered hash")
+	}
+
+	if _, _, _, err := CheckPartialMerkleTree(proof[:len(proof)-1]); err == nil {
+		t.Error("Expected error for truncated flags")
+	}
+
+	if _, _, _, err := CheckPartialMerkleTree(append(proof, 0)); err == nil {
+		t.Error("Expected error for trailing bytes")
+	}
+}
+
+func TestPartialMerkleTreeOfDefaultTree(t *testing.T) {
+	tree, _ := NewTree([]string{"A", "B"})
+
+	if _, err := tree.PartialMerkleTree(0); err == nil {
+		t.Error("Expected error for a tree not built by NewBitcoinTree")
+	}
+}
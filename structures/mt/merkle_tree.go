@@ -3,6 +3,7 @@ package mt
 import (
 	"errors"
 	"math"
+	"math/bits"
 
 	. "github.com/SimoneStefani/thesis-algorithms/structures/common"
 )
@@ -11,6 +12,13 @@ type MerkleTree struct {
 	Root       *Node
 	merkleRoot string
 	Leaves     []*Node
+
+	// the number of transactions, without the duplicate of an odd last leaf
+	length int
+	hasher Hasher
+
+	// the number of txids of a tree built by NewBitcoinTree
+	transactions int
 }
 
 type Node struct {
@@ -26,8 +34,41 @@ type VerificationNode struct {
 	isLeft bool
 }
 
+// Hasher computes the digests of the nodes: a leaf from its transaction and
+// an inner node from the digests of its children.
+//
+// Promote chooses what happens to the last node of a level with an odd
+// number of nodes. By default it is paired with itself, as in Bitcoin. When
+// Promote is set it moves up unchanged instead, which gives the tree of
+// RFC 6962: the left subtree of every node is the largest perfect tree, so
+// the nodes of a tree stay the same as transactions are appended, see
+// Append.
+type Hasher struct {
+	Leaf    func(tr string) string
+	Combine func(left string, right string) string
+	Promote bool
+}
+
+// DefaultHasher hashes the transactions and the concatenated digests of the
+// children twice, like CheckPath.
+var DefaultHasher = Hasher{
+	Leaf: func(tr string) string {
+		return HashTransaction(HashTransaction(tr))
+	},
+	Combine: func(left string, right string) string {
+		return HashTransaction(HashTransaction(left + right))
+	},
+}
+
 func NewTree(data []string) (*MerkleTree, error) {
-	root, leaves, err := buildWithContent(data)
+	return NewTreeWithHasher(data, DefaultHasher)
+}
+
+// NewTreeWithHasher builds the tree like NewTree with the digests computed
+// by hasher. The proofs of such a tree are only checked by CheckPath for
+// DefaultHasher.
+func NewTreeWithHasher(data []string, hasher Hasher) (*MerkleTree, error) {
+	root, leaves, err := buildWithContent(data, hasher)
 
 	if err != nil {
 		return nil, err
@@ -37,6 +78,8 @@ func NewTree(data []string) (*MerkleTree, error) {
 		Root:       root,
 		merkleRoot: root.hash,
 		Leaves:     leaves,
+		length:     len(data),
+		hasher:     hasher,
 	}
 
 	return t, nil
@@ -69,6 +112,136 @@ func CheckPath(tr string, roothash string, path []VerificationNode) bool {
 	return hash == roothash
 }
 
+// Prove returns the siblings met on the way from the leaf at position index
+// up to the root. The duplicate padding an odd last leaf is not a
+// transaction and has no position of its own.
+func (t *MerkleTree) Prove(index int) ([]VerificationNode, error) {
+	if index < 0 || index >= t.length {
+		return nil, errors.New("error: index out of range")
+	}
+
+	return computeMerklePath(index, t), nil
+}
+
+func (t *MerkleTree) MerkleRoot() string {
+	return t.merkleRoot
+}
+
+// Length returns the number of transactions of the tree.
+func (t *MerkleTree) Length() int {
+	return t.length
+}
+
+// Append adds a transaction to a tree whose hasher promotes odd nodes. Only
+// the nodes on the right edge of the tree are rebuilt: the tree is the
+// perfect subtrees given by the binary representation of its length, the
+// largest on the left, and the new leaf merges with the smallest ones like
+// a binary counter.
+func (t *MerkleTree) Append(tr string) error {
+	if !t.hasher.Promote {
+		return errors.New("error: only trees promoting odd nodes can be appended to")
+	}
+
+	var perfect []*Node
+	for start, n := 0, t.length; n > 0; {
+		size := 1 << uint(bits.Len(uint(n))-1)
+		perfect = append(perfect, t.Subtree(start, size))
+		start += size
+		n -= size
+	}
+
+	leaf := &Node{hash: t.hasher.Leaf(tr), data: tr}
+	node := leaf
+	for n := t.length; n&1 == 1; n >>= 1 {
+		node = t.join(perfect[len(perfect)-1], node)
+		perfect = perfect[:len(perfect)-1]
+	}
+
+	root := node
+	for i := len(perfect) - 1; i >= 0; i-- {
+		root = t.join(perfect[i], root)
+	}
+	root.Parent = nil
+
+	t.Root, t.merkleRoot = root, root.hash
+	t.Leaves = append(t.Leaves, leaf)
+	t.length++
+	return nil
+}
+
+// Subtree returns the root of the perfect subtree of the size leaves from
+// start, size being a power of two and start a multiple of it, of a tree
+// whose hasher promotes odd nodes. Such a node is in every tree holding the
+// leaves, whatever its length.
+func (t *MerkleTree) Subtree(start int, size int) *Node {
+	node := t.Leaves[start]
+	for ; size > 1; size >>= 1 {
+		node = node.Parent
+	}
+	return node
+}
+
+func (t *MerkleTree) join(left *Node, right *Node) *Node {
+	n := &Node{
+		Left:  left,
+		Right: right,
+		hash:  t.hasher.Combine(left.hash, right.hash),
+	}
+	left.Parent = n
+	right.Parent = n
+	return n
+}
+
+// MarshalPath flattens a path into 1+HashSize bytes per node: a side byte,
+// 1 when the sibling is on the left, and the decoded hash of the sibling.
+func MarshalPath(path []VerificationNode) ([]byte, error) {
+	buffer := make([]byte, 0, len(path)*(1+HashSize))
+
+	for _, node := range path {
+		raw, err := DecodeHash(node.hash)
+		if err != nil {
+			return nil, err
+		}
+		side := byte(0)
+		if node.isLeft {
+			side = 1
+		}
+		buffer = append(buffer, side)
+		buffer = append(buffer, raw...)
+	}
+
+	return buffer, nil
+}
+
+func UnmarshalPath(data []byte) ([]VerificationNode, error) {
+	if len(data)%(1+HashSize) != 0 {
+		return nil, errors.New("error: malformed path")
+	}
+
+	var path []VerificationNode
+	for i := 0; i < len(data); i += 1 + HashSize {
+		if data[i] > 1 {
+			return nil, errors.New("error: malformed path")
+		}
+		path = append(path, VerificationNode{
+			hash:   EncodeHash(data[i+1 : i+1+HashSize]),
+			isLeft: data[i] == 1,
+		})
+	}
+
+	return path, nil
+}
+
+// Hash returns the digest of the node.
+func (node *Node) Hash() string {
+	return node.hash
+}
+
+// Data returns the transaction of a leaf, empty for inner nodes.
+func (node *Node) Data() string {
+	return node.data
+}
+
 func (root *Node) Depth() int {
 	if root == nil {
 		return 0
@@ -115,7 +288,7 @@ func isLeftChild(node *Node) bool {
 	return node.Parent.Left.hash == node.hash
 }
 
-func buildWithContent(data []string) (*Node, []*Node, error) {
+func buildWithContent(data []string, hasher Hasher) (*Node, []*Node, error) {
 	if len(data) == 0 {
 		return nil, nil, errors.New("Error: cannot construct tree with no content.")
 	}
@@ -123,12 +296,16 @@ func buildWithContent(data []string) (*Node, []*Node, error) {
 	var leaves []*Node
 	for _, tr := range data {
 		leaves = append(leaves, &Node{
-			hash: HashTransaction(HashTransaction(tr)),
+			hash: hasher.Leaf(tr),
 			data: tr,
 		})
 	}
 
-	if len(leaves)%2 == 1 {
+	if hasher.Promote && len(leaves) == 1 {
+		return leaves[0], leaves, nil
+	}
+
+	if len(leaves)%2 == 1 && !hasher.Promote {
 		duplicate := &Node{
 			hash: leaves[len(leaves)-1].hash,
 			data: leaves[len(leaves)-1].data,
@@ -136,14 +313,18 @@ func buildWithContent(data []string) (*Node, []*Node, error) {
 		leaves = append(leaves, duplicate)
 	}
 
-	root := buildIntermediate(leaves)
+	root := buildIntermediate(leaves, hasher)
 	return root, leaves, nil
 }
 
-func buildIntermediate(nl []*Node) *Node {
+func buildIntermediate(nl []*Node, hasher Hasher) *Node {
 	var nodes []*Node
 
 	for i := 0; i < len(nl); i += 2 {
+		if i+1 == len(nl) && hasher.Promote {
+			nodes = append(nodes, nl[i])
+			continue
+		}
 
 		var left, right int = i, i + 1
 		if i+1 == len(nl) {
@@ -153,7 +334,7 @@ func buildIntermediate(nl []*Node) *Node {
 		n := &Node{
 			Left:  nl[left],
 			Right: nl[right],
-			hash:  HashTransaction(HashTransaction(nl[left].hash + nl[right].hash)),
+			hash:  hasher.Combine(nl[left].hash, nl[right].hash),
 		}
 		nodes = append(nodes, n)
 
@@ -165,5 +346,5 @@ func buildIntermediate(nl []*Node) *Node {
 		}
 	}
 
-	return buildIntermediate(nodes)
+	return buildIntermediate(nodes, hasher)
 }
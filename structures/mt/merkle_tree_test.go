@@ -3,6 +3,8 @@ package mt
 import (
 	"strconv"
 	"testing"
+
+	"github.com/SimoneStefani/thesis-algorithms/structures/internal/benchdata"
 )
 
 func TestBuildMerkleTreeWithNoElements(t *testing.T) {
@@ -50,3 +52,152 @@ func TestVerifyMerkleTreeInvalidTransaction(t *testing.T) {
 		t.Error("Invalid verification")
 	}
 }
+
+func TestProveMerkleTreeEveryPosition(t *testing.T) {
+	data := []string{"A", "B", "C", "D", "E"}
+	tree, _ := NewTree(data)
+
+	// E is the odd last leaf, paired with its own duplicate
+	for i, tr := range data {
+		path, err := tree.Prove(i)
+
+		if err != nil {
+			t.Error("Expected error nil, got " + err.Error())
+		}
+
+		if !CheckPath(tr, tree.MerkleRoot(), path) {
+			t.Error("Expected true for " + tr + ", got false")
+		}
+	}
+
+	if _, err := tree.Prove(-1); err == nil {
+		t.Error("Expected error for index -1")
+	}
+
+	// the duplicate padding the odd last leaf is not a transaction
+	if _, err := tree.Prove(5); err == nil {
+		t.Error("Expected error for index 5")
+	}
+}
+
+func TestMarshalMerkleTreePath(t *testing.T) {
+	tree, _ := NewTree([]string{"A", "B", "C", "D", "E"})
+	path, _ := tree.Prove(2)
+	data, err := MarshalPath(path)
+
+	if err != nil {
+		t.Error("Expected error nil, got " + err.Error())
+	}
+
+	decoded, err := UnmarshalPath(data)
+
+	if err != nil {
+		t.Error("Expected error nil, got " + err.Error())
+	}
+
+	if !CheckPath("C", tree.MerkleRoot(), decoded) {
+		t.Error("Expected true, got false")
+	}
+
+	if len(data) != len(path)*(1+32) {
+		t.Error("Expected " + strconv.Itoa(len(path)*(1+32)) + " bytes, got " + strconv.Itoa(len(data)))
+	}
+}
+
+// promoteHasher hashes like DefaultHasher with the odd nodes promoted.
+var promoteHasher = Hasher{
+	Leaf:    DefaultHasher.Leaf,
+	Combine: DefaultHasher.Combine,
+	Promote: true,
+}
+
+func TestPromoteMerkleTreeOddNode(t *testing.T) {
+	tree, _ := NewTreeWithHasher([]string{"A", "B", "C"}, promoteHasher)
+	h := DefaultHasher
+
+	expected := h.Combine(h.Combine(h.Leaf("A"), h.Leaf("B")), h.Leaf("C"))
+	if tree.MerkleRoot() != expected {
+		t.Error("Expected C promoted next to H(A, B), got " + tree.MerkleRoot())
+	}
+
+	if len(tree.Leaves) != 3 {
+		t.Error("Expected 3 leaves without duplicate, got " + strconv.Itoa(len(tree.Leaves)))
+	}
+}
+
+func TestAppendMerkleTreeMatchesBuild(t *testing.T) {
+	tree, _ := NewTreeWithHasher([]string{"0"}, promoteHasher)
+
+	for n := 2; n <= 33; n++ {
+		if err := tree.Append(strconv.Itoa(n - 1)); err != nil {
+			t.Fatal("Expected error nil, got " + err.Error())
+		}
+
+		var data []string
+		for i := 0; i < n; i++ {
+			data = append(data, strconv.Itoa(i))
+		}
+		built, _ := NewTreeWithHasher(data, promoteHasher)
+
+		if tree.MerkleRoot() != built.MerkleRoot() || tree.Length() != n {
+			t.Error("Expected the root of the built tree for " + strconv.Itoa(n) + " leaves")
+		}
+
+		// the paths follow the rebuilt parents
+		for i := 0; i < n; i++ {
+			path, _ := tree.Prove(i)
+			if !CheckPath(strconv.Itoa(i), tree.MerkleRoot(), path) {
+				t.Error("Expected a valid path of " + strconv.Itoa(i) + " in " + strconv.Itoa(n) + " leaves")
+			}
+		}
+	}
+}
+
+func TestAppendMerkleTreeRequiresPromote(t *testing.T) {
+	tree, _ := NewTree([]string{"A"})
+
+	if err := tree.Append("B"); err == nil {
+		t.Error("Expected error for a tree duplicating odd nodes")
+	}
+}
+
+func TestBuildMerkleTreeWithHasher(t *testing.T) {
+	data := []string{"A", "B", "C"}
+	tree, _ := NewTree(data)
+	same, _ := NewTreeWithHasher(data, DefaultHasher)
+
+	if same.MerkleRoot() != tree.MerkleRoot() {
+		t.Error("Expected the root of NewTree with DefaultHasher")
+	}
+
+	concat := Hasher{
+		Leaf:    func(tr string) string { return tr },
+		Combine: func(left string, right string) string { return "(" + left + right + ")" },
+	}
+	custom, _ := NewTreeWithHasher(data, concat)
+
+	if custom.MerkleRoot() != "((AB)(CC))" {
+		t.Error("Expected ((AB)(CC)), got " + custom.MerkleRoot())
+	}
+}
+
+func BenchmarkBuild(b *testing.B) {
+	benchdata.Run(b, func(data []string) func() {
+		return func() { NewTree(data) }
+	})
+}
+
+func BenchmarkProve(b *testing.B) {
+	benchdata.Run(b, func(data []string) func() {
+		tree, _ := NewTree(data)
+		return func() { tree.Prove(len(data) / 2) }
+	})
+}
+
+func BenchmarkVerify(b *testing.B) {
+	benchdata.Run(b, func(data []string) func() {
+		tree, _ := NewTree(data)
+		path, _ := tree.Prove(len(data) / 2)
+		return func() { CheckPath(data[len(data)/2], tree.MerkleRoot(), path) }
+	})
+}
@@ -0,0 +1,188 @@
+package xorfilter
+
+import (
+	"errors"
+	"math/bits"
+	"sort"
+
+	. "github.com/SimoneStefani/thesis-algorithms/structures/common"
+)
+
+// maxAttempts bounds the number of seeds tried before construction fails,
+// each attempt succeeds with high probability.
+const maxAttempts = 100
+
+// XorFilter is an immutable probabilistic set storing an 8-bit fingerprint
+// for every element in three slots h0, h1 and h2 such that
+// fingerprint(x) = F[h0(x)] xor F[h1(x)] xor F[h2(x)]
+// The table is built by peeling a 3-hypergraph and is about 1.23 bytes per
+// element, see Graf and Lemire, "Xor Filters: Faster and Smaller Than Bloom
+// and Cuckoo Filters".
+type XorFilter struct {
+	seed         uint64
+	blockLength  uint32
+	fingerprints []uint8
+}
+
+type keyIndex struct {
+	hash  uint64
+	index uint32
+}
+
+// NewXorFilterFromData builds a filter containing every transaction of data.
+// Duplicated transactions are only added once.
+func NewXorFilterFromData(data []string) (*XorFilter, error) {
+	if len(data) == 0 {
+		return nil, errors.New("Error: cannot construct filter with no content.")
+	}
+
+	keys := make([]uint64, 0, len(data))
+	for _, tr := range data {
+		keys = append(keys, HashTransaction64(tr))
+	}
+
+	return newXorFilter(unique(keys))
+}
+
+func newXorFilter(keys []uint64) (*XorFilter, error) {
+	capacity := 32 + uint32(1.23*float64(len(keys)))
+	capacity = capacity / 3 * 3
+
+	xf := &XorFilter{
+		blockLength:  capacity / 3,
+		fingerprints: make([]uint8, capacity),
+	}
+
+	counts := make([]uint8, capacity)
+	xors := make([]uint64, capacity)
+	queue := make([]uint32, 0, capacity)
+	stack := make([]keyIndex, 0, len(keys))
+
+	rngCounter := uint64(1)
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		xf.seed = splitmix64(&rngCounter)
+
+		for i := range counts {
+			counts[i] = 0
+			xors[i] = 0
+		}
+		for _, key := range keys {
+			hash := mixsplit(key, xf.seed)
+			for _, h := range xf.slots(hash) {
+				counts[h]++
+				xors[h] ^= hash
+			}
+		}
+
+		// peel the slots used by a single key until none is left
+		queue = queue[:0]
+		stack = stack[:0]
+		for i, count := range counts {
+			if count == 1 {
+				queue = append(queue, uint32(i))
+			}
+		}
+		for len(queue) > 0 {
+			i := queue[len(queue)-1]
+			queue = queue[:len(queue)-1]
+			if counts[i] != 1 {
+				continue
+			}
+			hash := xors[i]
+			stack = append(stack, keyIndex{hash: hash, index: i})
+			for _, h := range xf.slots(hash) {
+				counts[h]--
+				xors[h] ^= hash
+				if counts[h] == 1 {
+					queue = append(queue, h)
+				}
+			}
+		}
+
+		if len(stack) == len(keys) {
+			break
+		}
+	}
+
+	if len(stack) != len(keys) {
+		return nil, errors.New("error: cannot construct xor filter")
+	}
+
+	// assign the fingerprints in reverse peeling order so that the slot of
+	// every key is the last of its three to be written
+	for i := len(stack) - 1; i >= 0; i-- {
+		ki := stack[i]
+		h := xf.slots(ki.hash)
+		xf.fingerprints[ki.index] = 0
+		xf.fingerprints[ki.index] = fingerprint(ki.hash) ^ xf.fingerprints[h[0]] ^ xf.fingerprints[h[1]] ^ xf.fingerprints[h[2]]
+	}
+
+	return xf, nil
+}
+
+// Test returns false if tr is not in the filter and true if it probably is.
+func (xf *XorFilter) Test(tr string) bool {
+	hash := mixsplit(HashTransaction64(tr), xf.seed)
+	h := xf.slots(hash)
+
+	return fingerprint(hash) == xf.fingerprints[h[0]]^xf.fingerprints[h[1]]^xf.fingerprints[h[2]]
+}
+
+// Size returns the number of bytes used by the fingerprints.
+func (xf *XorFilter) Size() int {
+	return len(xf.fingerprints)
+}
+
+// FalsePositiveRate returns the expected false positive rate 2^-8.
+func (xf *XorFilter) FalsePositiveRate() float64 {
+	return 1.0 / 256
+}
+
+// slots returns the position of a hash in each of the three blocks.
+func (xf *XorFilter) slots(hash uint64) [3]uint32 {
+	return [3]uint32{
+		reduce(uint32(hash), xf.blockLength),
+		reduce(uint32(bits.RotateLeft64(hash, 21)), xf.blockLength) + xf.blockLength,
+		reduce(uint32(bits.RotateLeft64(hash, 42)), xf.blockLength) + 2*xf.blockLength,
+	}
+}
+
+func fingerprint(hash uint64) uint8 {
+	return uint8(hash ^ (hash >> 32))
+}
+
+// reduce maps hash to [0, n) without a modulo.
+func reduce(hash uint32, n uint32) uint32 {
+	return uint32((uint64(hash) * uint64(n)) >> 32)
+}
+
+func mixsplit(key uint64, seed uint64) uint64 {
+	h := key + seed
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+func splitmix64(seed *uint64) uint64 {
+	*seed += 0x9E3779B97F4A7C15
+	z := *seed
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	return z ^ (z >> 31)
+}
+
+// unique sorts keys and drops the duplicates, which would never peel.
+func unique(keys []uint64) []uint64 {
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	result := keys[:0]
+	for i, key := range keys {
+		if i == 0 || key != keys[i-1] {
+			result = append(result, key)
+		}
+	}
+	return result
+}
@@ -0,0 +1,65 @@
+package xorfilter
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestBuildXorFilterWithNoElements(t *testing.T) {
+	_, err := NewXorFilterFromData([]string{})
+
+	if err == nil {
+		t.Error("Expected error for empty filter")
+	}
+}
+
+func TestXorFilterContainsAddedElements(t *testing.T) {
+	var data []string
+	for i := 0; i < 10000; i++ {
+		data = append(data, "member-"+strconv.Itoa(i))
+	}
+	xf, err := NewXorFilterFromData(data)
+
+	if err != nil {
+		t.Fatal("Expected error nil, got " + err.Error())
+	}
+
+	for _, tr := range data {
+		if !xf.Test(tr) {
+			t.Error("Expected true for " + tr + ", got false")
+		}
+	}
+}
+
+func TestXorFilterWithDuplicates(t *testing.T) {
+	xf, err := NewXorFilterFromData([]string{"A", "B", "A", "C", "B"})
+
+	if err != nil {
+		t.Fatal("Expected error nil, got " + err.Error())
+	}
+
+	for _, tr := range []string{"A", "B", "C"} {
+		if !xf.Test(tr) {
+			t.Error("Expected true for " + tr + ", got false")
+		}
+	}
+}
+
+func TestXorFilterFalsePositiveRate(t *testing.T) {
+	var data []string
+	for i := 0; i < 10000; i++ {
+		data = append(data, "member-"+strconv.Itoa(i))
+	}
+	xf, _ := NewXorFilterFromData(data)
+
+	positives := 0
+	for i := 0; i < 100000; i++ {
+		if xf.Test("probe-" + strconv.Itoa(i)) {
+			positives++
+		}
+	}
+
+	if positives > 600 {
+		t.Error("Expected about 390 false positives, got " + strconv.Itoa(positives))
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	. "github.com/SimoneStefani/thesis-algorithms/utilities"
+)
+
+// sweepMetrics are the result columns reported by the sweep, the median of
+// the trials at every input size.
+var sweepMetrics = []string{"build_time", "build_retained", "proof_length", "verification_time", "verification_hashes"}
+
+// expectedComplexity returns the growth of a metric of algo with the input
+// size, or an empty string where it is not one of the fitted models, e.g.
+// the square root proofs of chl.
+func expectedComplexity(algo string, metric string) string {
+	switch metric {
+	case "build_time", "build_retained":
+		return "O(n)"
+	case "proof_length", "verification_time", "verification_hashes":
+		switch algo {
+		case "mt", "fmt", "amt", "kmt", "sl":
+			return "O(log n)"
+		case "hl":
+			return "O(n)"
+		case "bf", "cf", "xf":
+			return "O(1)"
+		}
+	}
+	return ""
+}
+
+// runSweep runs build, prove and verify for every algorithm and input size
+// and writes one consolidated table, then fits every metric of every
+// algorithm to the complexity models:
+//
+//	thesis sweep -algo=mt,hl,sl -size=1000,10000,100000 -iter=5
+//
+// The inputs are prefixes of the file given with -name, in the folder given
+// with -in, or of data generated like the gen subcommand when no file is
+// given.
+func runSweep(args []string) error {
+	flags := newFlagSet("sweep", "thesis sweep -algo=mt,hl,sl -size=1000,10000,100000 -iter=5")
+
+	algorithms := flags.String("algo", "mt,hl,sl", "the algorithms to sweep, comma separated")
+	sizes := flags.String("size", "1000,10000,100000", "the input sizes to sweep, comma separated")
+	name := flags.String("name", "", "the input file to slice, generated data when empty")
+	kind := flags.String("kind", "uniform", "the kind of generated data (see gen)")
+	iterations := flags.Int("iter", 5, "number of iterations at every size")
+	parameters := flags.String("k", "", "the structure parameter of chl and kmt (defaults to their first default)")
+	fpr := flags.Float64("fpr", 0.01, "the target false positive rate of the filters")
+	seed := flags.Int64("seed", 1, "the seed of the generated data")
+	input, maxLineSize := InputFlags(flags)
+	inputDir, outputDir := PathFlags(flags)
+
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	n, err := ParseIntList(*sizes)
+	if err != nil {
+		return &usageError{err.Error()}
+	}
+	if len(n) < 2 {
+		return &usageError{"error: the sweep needs at least two sizes"}
+	}
+	sort.Ints(n)
+	if err := checkInput(*input); err != nil {
+		return err
+	}
+
+	k, err := ParseIntList(*parameters)
+	if err != nil {
+		return &usageError{err.Error()}
+	}
+
+	// fail before running any experiment of an unknown algorithm
+	algos := strings.Split(*algorithms, ",")
+	for _, algo := range algos {
+		if _, err := newStructure(algo, 0, *fpr); err != nil {
+			return &usageError{err.Error()}
+		}
+	}
+
+	// load or generate the largest input, the smaller ones are its prefixes
+	var source []string
+	inputName := *kind
+	if *name != "" {
+		inputs, err := ResolveInputs(*inputDir, *name)
+		if err != nil {
+			return err
+		}
+		if len(inputs) > 1 {
+			return &usageError{fmt.Sprintf("error: the sweep slices a single input, %s matches %d files", *name, len(inputs))}
+		}
+		source, err = LoadInput(inputs[0], *input, *maxLineSize)
+		if err != nil {
+			return err
+		}
+		inputName = strings.TrimSuffix(filepath.Base(inputs[0]), filepath.Ext(inputs[0]))
+		if inputs[0] == Stdio {
+			inputName = "stdin"
+		}
+	} else {
+		source, err = GenerateData(*kind, n[len(n)-1], 64, 64, 0.1, *seed)
+		if err != nil {
+			return err
+		}
+	}
+	if len(source) < n[len(n)-1] {
+		return fmt.Errorf("error: %s holds %d elements, less than %d", *name, len(source), n[len(n)-1])
+	}
+
+	cmd := &Command{
+		Operation:         "all",
+		Iterations:        *iterations,
+		FalsePositiveRate: *fpr,
+		Positions:         "middle",
+	}
+
+	table := [][]string{append([]string{"algo", "size"}, sweepMetrics...)}
+	fits := [][]string{{"algo", "metric", "model", "expected", "r2", "a", "b"}}
+
+	for _, algo := range algos {
+		series := make(map[string][]float64)
+		for _, size := range n {
+			data := append([]string(nil), source[:size]...)
+			if algo == "sl" {
+				sort.Strings(data)
+			}
+
+			param := structureParameters(algo, k, size)[0]
+			newS := func() (structure, error) {
+				return newStructure(algo, param, *fpr)
+			}
+
+			fmt.Fprintf(os.Stderr, "Running %s with %d elements...\n", algo, size)
+			results, err := runExperiment(data, newS, cmd)
+			if err != nil {
+				return err
+			}
+
+			medians := make(map[string]int64)
+			columns := results.averages()
+			for i, column := range results.columnNames() {
+				medians[column] = Median(columns[i])
+			}
+
+			row := []string{algo, strconv.Itoa(size)}
+			for _, metric := range sweepMetrics {
+				row = append(row, strconv.FormatInt(medians[metric], 10))
+				series[metric] = append(series[metric], float64(medians[metric]))
+			}
+			table = append(table, row)
+		}
+
+		for _, metric := range sweepMetrics {
+			fit := BestFit(FitComplexity(n, series[metric]))
+			expected := expectedComplexity(algo, metric)
+			if expected != "" && fit.Model != expected {
+				fmt.Fprintf(os.Stderr, "%s %s grows as %s, expected %s\n", algo, metric, fit.Model, expected)
+			}
+
+			fits = append(fits, []string{algo, metric, fit.Model, expected,
+				strconv.FormatFloat(fit.R2, 'f', 4, 64),
+				strconv.FormatFloat(fit.A, 'g', 6, 64),
+				strconv.FormatFloat(fit.B, 'g', 6, 64)})
+		}
+	}
+
+	// output file name pattern: sweep_[inputName].csv and
+	// sweep_fit_[inputName].csv, e.g. sweep_uniform.csv
+	if err := writeOutput(*outputDir, "sweep_"+inputName+".csv", joinTable(table), nil); err != nil {
+		return err
+	}
+	return writeOutput(*outputDir, "sweep_fit_"+inputName+".csv", joinTable(fits), nil)
+}
+
+func joinTable(rows [][]string) string {
+	var lines []string
+	for _, row := range rows {
+		lines = append(lines, strings.Join(row, ","))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,336 @@
+package tlog
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/SimoneStefani/thesis-algorithms/structures/mt"
+	. "github.com/SimoneStefani/thesis-algorithms/utilities"
+)
+
+// The files of a log in its folder: the entries, each preceded by its
+// length as 4 bytes big endian like the prefixed input, and the seed of
+// the signing key.
+const (
+	entriesFile = "entries.log"
+	keyFile     = "log.key"
+)
+
+// Log is an append-only log of entries committed to by a Merkle tree whose
+// root is signed at every append. The entries are the transactions of the
+// tree, nil until the first one.
+type Log struct {
+	mu    sync.RWMutex
+	tree  *mt.MerkleTree
+	index map[string]int
+	head  *SignedHead
+	file  *os.File
+	key   ed25519.PrivateKey
+
+	// the end of the last complete entry in the file, and the error that
+	// left the file past it, after which no entry is appended
+	offset int64
+	failed error
+}
+
+// SignedHead is the commitment of the log to its first TreeSize entries.
+type SignedHead struct {
+	TreeSize  int    `json:"tree_size"`
+	RootHash  []byte `json:"root_hash"`
+	Timestamp int64  `json:"timestamp"`
+	Signature []byte `json:"signature"`
+}
+
+// message returns the bytes signed by the log: the tree size, the hex root
+// and the timestamp in milliseconds, one per line.
+func (h *SignedHead) message() []byte {
+	return []byte(fmt.Sprintf("thesis-tlog\n%d\n%s\n%d\n", h.TreeSize, hex.EncodeToString(h.RootHash), h.Timestamp))
+}
+
+// VerifyHead tells whether the head was signed by the key of a log.
+func VerifyHead(key ed25519.PublicKey, head *SignedHead) bool {
+	return len(key) == ed25519.PublicKeySize && ed25519.Verify(key, head.message(), head.Signature)
+}
+
+// Open opens the log stored in dir, creating the folder, the entries and
+// a signing key if needed.
+func Open(dir string) (*Log, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	key, err := loadKey(filepath.Join(dir, keyFile))
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(dir, entriesFile)
+	entries, err := loadEntries(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	l := &Log{index: make(map[string]int), file: file, key: key, offset: info.Size()}
+	for _, entry := range entries {
+		if _, err := l.add(entry); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	l.sign()
+	return l, nil
+}
+
+// loadKey reads the hex seed of the signing key at path, or writes a new
+// one.
+func loadKey(path string) (ed25519.PrivateKey, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		seed := make([]byte, ed25519.SeedSize)
+		if _, err := rand.Read(seed); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, []byte(hex.EncodeToString(seed)+"\n"), 0600); err != nil {
+			return nil, err
+		}
+		return ed25519.NewKeyFromSeed(seed), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	seed, err := hex.DecodeString(string(trimNewline(content)))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, errors.New("error: invalid key in " + path)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+func trimNewline(content []byte) []byte {
+	for len(content) > 0 && (content[len(content)-1] == '\n' || content[len(content)-1] == '\r') {
+		content = content[:len(content)-1]
+	}
+	return content
+}
+
+// loadEntries reads the entries at path. A truncated last entry, left by a
+// crash in the middle of an append, is cut off the file.
+func loadEntries(path string) ([][]byte, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries, err := ReadPrefixed(file)
+	if err == nil {
+		return entries, nil
+	}
+
+	// keep the complete entries
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	offset := 0
+	entries = nil
+	for offset+4 <= len(content) {
+		n := int(binary.BigEndian.Uint32(content[offset:]))
+		if offset+4+n > len(content) {
+			break
+		}
+		entries = append(entries, content[offset+4:offset+4+n])
+		offset += 4 + n
+	}
+	return entries, os.Truncate(path, int64(offset))
+}
+
+// add adds an entry to the tree and the index of the log.
+func (l *Log) add(entry []byte) (int, error) {
+	index := l.size()
+	var err error
+	if l.tree == nil {
+		l.tree, err = mt.NewTreeWithHasher([]string{string(entry)}, hasher)
+	} else {
+		err = l.tree.Append(string(entry))
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	leaf := LeafHash(entry)
+	if _, ok := l.index[string(leaf)]; !ok {
+		l.index[string(leaf)] = index
+	}
+	return index, nil
+}
+
+func (l *Log) size() int {
+	if l.tree == nil {
+		return 0
+	}
+	return l.tree.Length()
+}
+
+// sign signs the current root.
+func (l *Log) sign() {
+	head := &SignedHead{
+		TreeSize:  l.size(),
+		RootHash:  root(l.tree, l.size()),
+		Timestamp: time.Now().UnixNano() / int64(time.Millisecond),
+	}
+	head.Signature = ed25519.Sign(l.key, head.message())
+	l.head = head
+}
+
+// Append stores an entry, adds it to the tree and signs the new head. It
+// returns the index of the entry once it is on disk.
+func (l *Log) Append(entry []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.failed != nil {
+		return 0, l.failed
+	}
+
+	record := binary.BigEndian.AppendUint32(nil, uint32(len(entry)))
+	record = append(record, entry...)
+	if err := l.write(record); err != nil {
+		return 0, err
+	}
+	l.offset += int64(len(record))
+
+	index, err := l.add(entry)
+	if err != nil {
+		return 0, err
+	}
+	l.sign()
+	return index, nil
+}
+
+// write stores a record and syncs it. On error the file is cut back to the
+// last complete entry, so that a failed append doesn't come back after a
+// restart or corrupt the next ones; when even that fails the log refuses
+// any further append.
+func (l *Log) write(record []byte) error {
+	_, err := l.file.Write(record)
+	if err == nil {
+		err = l.file.Sync()
+	}
+	if err == nil {
+		return nil
+	}
+
+	if terr := l.file.Truncate(l.offset); terr != nil {
+		l.failed = fmt.Errorf("error: entries file past its last entry: %v", terr)
+	} else if serr := l.file.Sync(); serr != nil {
+		l.failed = fmt.Errorf("error: entries file past its last entry: %v", serr)
+	}
+	return err
+}
+
+// Close closes the entries file.
+func (l *Log) Close() error {
+	return l.file.Close()
+}
+
+// PublicKey returns the key verifying the heads of the log.
+func (l *Log) PublicKey() ed25519.PublicKey {
+	return l.key.Public().(ed25519.PublicKey)
+}
+
+// Head returns the latest signed head.
+func (l *Log) Head() *SignedHead {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.head
+}
+
+// Size returns the number of entries.
+func (l *Log) Size() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.size()
+}
+
+// Entry returns the entry at index.
+func (l *Log) Entry(index int) ([]byte, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if index < 0 || index >= l.size() {
+		return nil, errors.New("error: index out of range")
+	}
+	return []byte(l.tree.Leaves[index].Data()), nil
+}
+
+// IndexOf returns the index of the first entry with the leaf digest.
+func (l *Log) IndexOf(leaf []byte) (int, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	index, ok := l.index[string(leaf)]
+	return index, ok
+}
+
+// Root returns the root of the tree of the first size entries.
+func (l *Log) Root(size int) ([]byte, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if size < 0 || size > l.size() {
+		return nil, errRange
+	}
+	return root(l.tree, size), nil
+}
+
+// InclusionProof returns the audit path of the entry at index in the tree
+// of the first size entries, see VerifyInclusion.
+func (l *Log) InclusionProof(index int, size int) ([][]byte, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if size < 1 || size > l.size() {
+		return nil, errRange
+	}
+	if index < 0 || index >= size {
+		return nil, errors.New("error: index out of range")
+	}
+	return inclusion(l.tree, index, 0, size), nil
+}
+
+// ConsistencyProof returns the proof that the tree of the first entries is
+// a prefix of the tree of the second entries, see VerifyConsistency.
+func (l *Log) ConsistencyProof(first int, second int) ([][]byte, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if first < 0 || first > second || second > l.size() {
+		return nil, errRange
+	}
+	if first == 0 || first == second {
+		return [][]byte{}, nil
+	}
+	return consistency(l.tree, first, 0, second, true), nil
+}
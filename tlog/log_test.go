@@ -0,0 +1,205 @@
+package tlog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func appendEntries(t *testing.T, l *Log, n int) {
+	for i := l.Size(); i < n; i++ {
+		if _, err := l.Append([]byte("entry " + strconv.Itoa(i))); err != nil {
+			t.Fatal("Expected no error, got " + err.Error())
+		}
+	}
+}
+
+func TestEmptyRoot(t *testing.T) {
+	l, _ := Open(t.TempDir())
+	defer l.Close()
+
+	empty := sha256.Sum256(nil)
+	if hex.EncodeToString(l.Head().RootHash) != hex.EncodeToString(empty[:]) {
+		t.Error("Expected the digest of nothing, got " + hex.EncodeToString(l.Head().RootHash))
+	}
+}
+
+func TestRootOfTwoEntries(t *testing.T) {
+	l, _ := Open(t.TempDir())
+	defer l.Close()
+	appendEntries(t, l, 2)
+
+	expected := nodeHash(LeafHash([]byte("entry 0")), LeafHash([]byte("entry 1")))
+	if hex.EncodeToString(l.Head().RootHash) != hex.EncodeToString(expected) {
+		t.Error("Expected " + hex.EncodeToString(expected) + ", got " + hex.EncodeToString(l.Head().RootHash))
+	}
+}
+
+func TestInclusionProofs(t *testing.T) {
+	l, _ := Open(t.TempDir())
+	defer l.Close()
+	appendEntries(t, l, 20)
+
+	for size := 1; size <= 20; size++ {
+		root, _ := l.Root(size)
+		for index := 0; index < size; index++ {
+			entry, _ := l.Entry(index)
+			proof, err := l.InclusionProof(index, size)
+			if err != nil {
+				t.Fatal("Expected no error, got " + err.Error())
+			}
+
+			if !VerifyInclusion(LeafHash(entry), index, size, proof, root) {
+				t.Error("Expected valid proof of " + strconv.Itoa(index) + " in " + strconv.Itoa(size))
+			}
+
+			if VerifyInclusion(LeafHash([]byte("forged")), index, size, proof, root) {
+				t.Error("Expected forged entry to be rejected in " + strconv.Itoa(size))
+			}
+
+			if size > 1 && VerifyInclusion(LeafHash(entry), (index+1)%size, size, proof, root) {
+				t.Error("Expected wrong index to be rejected in " + strconv.Itoa(size))
+			}
+		}
+	}
+}
+
+func TestConsistencyProofs(t *testing.T) {
+	l, _ := Open(t.TempDir())
+	defer l.Close()
+	appendEntries(t, l, 20)
+
+	for second := 1; second <= 20; second++ {
+		secondRoot, _ := l.Root(second)
+		for first := 1; first <= second; first++ {
+			firstRoot, _ := l.Root(first)
+			proof, err := l.ConsistencyProof(first, second)
+			if err != nil {
+				t.Fatal("Expected no error, got " + err.Error())
+			}
+
+			if !VerifyConsistency(first, second, firstRoot, secondRoot, proof) {
+				t.Error("Expected " + strconv.Itoa(first) + " consistent with " + strconv.Itoa(second))
+			}
+
+			if first < second && VerifyConsistency(first, second, LeafHash([]byte("forged")), secondRoot, proof) {
+				t.Error("Expected forged root of " + strconv.Itoa(first) + " to be rejected")
+			}
+		}
+	}
+}
+
+func TestProofsOutOfRange(t *testing.T) {
+	l, _ := Open(t.TempDir())
+	defer l.Close()
+	appendEntries(t, l, 3)
+
+	if _, err := l.InclusionProof(3, 3); err == nil {
+		t.Error("Expected error for index out of range")
+	}
+
+	if _, err := l.InclusionProof(0, 4); err == nil {
+		t.Error("Expected error for size out of range")
+	}
+
+	if _, err := l.ConsistencyProof(3, 2); err == nil {
+		t.Error("Expected error for first larger than second")
+	}
+}
+
+func TestHeadSignature(t *testing.T) {
+	l, _ := Open(t.TempDir())
+	defer l.Close()
+	appendEntries(t, l, 5)
+
+	head := *l.Head()
+	if !VerifyHead(l.PublicKey(), &head) {
+		t.Error("Expected valid signature of the head")
+	}
+
+	head.TreeSize = 4
+	if VerifyHead(l.PublicKey(), &head) {
+		t.Error("Expected tampered head to be rejected")
+	}
+}
+
+func TestReopenKeepsEntriesAndKey(t *testing.T) {
+	dir := t.TempDir()
+	l, _ := Open(dir)
+	appendEntries(t, l, 7)
+	root := l.Head().RootHash
+	key := l.PublicKey()
+	l.Close()
+
+	l, err := Open(dir)
+	if err != nil {
+		t.Fatal("Expected no error, got " + err.Error())
+	}
+	defer l.Close()
+
+	if l.Size() != 7 || hex.EncodeToString(l.Head().RootHash) != hex.EncodeToString(root) {
+		t.Error("Expected 7 entries, got " + strconv.Itoa(l.Size()))
+	}
+
+	if !key.Equal(l.PublicKey()) {
+		t.Error("Expected the same key after reopening")
+	}
+}
+
+func TestReopenDropsTruncatedEntry(t *testing.T) {
+	dir := t.TempDir()
+	l, _ := Open(dir)
+	appendEntries(t, l, 3)
+	l.Close()
+
+	// a crash in the middle of an append
+	file, _ := os.OpenFile(filepath.Join(dir, entriesFile), os.O_WRONLY|os.O_APPEND, 0644)
+	file.Write([]byte{0, 0, 0, 9, 'a'})
+	file.Close()
+
+	l, err := Open(dir)
+	if err != nil {
+		t.Fatal("Expected no error, got " + err.Error())
+	}
+	appendEntries(t, l, 4)
+	l.Close()
+
+	l, _ = Open(dir)
+	defer l.Close()
+	if l.Size() != 4 {
+		t.Error("Expected 4 entries, got " + strconv.Itoa(l.Size()))
+	}
+}
+
+func TestFailedAppendIsNotStored(t *testing.T) {
+	dir := t.TempDir()
+	l, _ := Open(dir)
+	appendEntries(t, l, 3)
+
+	// a file that can't be written, nor cut back
+	file := l.file
+	l.file, _ = os.Open(filepath.Join(dir, entriesFile))
+
+	if _, err := l.Append([]byte("lost")); err == nil {
+		t.Error("Expected error for a failed write")
+	}
+
+	if _, err := l.Append([]byte("next")); err == nil {
+		t.Error("Expected appends to be refused after a failed write")
+	}
+
+	if l.Size() != 3 || l.Head().TreeSize != 3 {
+		t.Error("Expected 3 entries, got " + strconv.Itoa(l.Size()))
+	}
+	l.Close()
+	file.Close()
+
+	l, _ = Open(dir)
+	defer l.Close()
+	if l.Size() != 3 {
+		t.Error("Expected 3 entries after reopening, got " + strconv.Itoa(l.Size()))
+	}
+}
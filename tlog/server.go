@@ -0,0 +1,208 @@
+package tlog
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// MaxEntrySize is the largest entry accepted by the server.
+const MaxEntrySize = 1 << 20
+
+// The bodies of the endpoints. Byte slices are base64 in JSON.
+type (
+	addRequest struct {
+		Data []byte `json:"data"`
+	}
+
+	addResponse struct {
+		Index    int    `json:"index"`
+		LeafHash []byte `json:"leaf_hash"`
+	}
+
+	entryResponse struct {
+		Index int    `json:"index"`
+		Data  []byte `json:"data"`
+	}
+
+	keyResponse struct {
+		Algorithm string `json:"algorithm"`
+		PublicKey []byte `json:"public_key"`
+	}
+
+	inclusionResponse struct {
+		Index     int      `json:"index"`
+		TreeSize  int      `json:"tree_size"`
+		LeafHash  []byte   `json:"leaf_hash"`
+		AuditPath [][]byte `json:"audit_path"`
+	}
+
+	consistencyResponse struct {
+		First       int      `json:"first"`
+		Second      int      `json:"second"`
+		Consistency [][]byte `json:"consistency"`
+	}
+
+	errorResponse struct {
+		Error string `json:"error"`
+	}
+)
+
+// NewHandler serves the log over HTTP with JSON bodies:
+//
+//	POST /entries                            add {"data": base64}
+//	GET  /entries/{index}                    the entry at index
+//	GET  /head                               the latest signed head
+//	GET  /key                                the public key of the heads
+//	GET  /proof/inclusion?index=i&size=n     the audit path of entry i
+//	GET  /proof/inclusion?hash=h&size=n      the same for the entry with leaf hash h, in hex
+//	GET  /proof/consistency?first=m&second=n the consistency of size m with size n
+//
+// The size of the proofs defaults to the one of the latest head.
+func NewHandler(l *Log) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/entries", method(http.MethodPost, func(w http.ResponseWriter, r *http.Request) {
+		var req addRequest
+		body := http.MaxBytesReader(w, r.Body, 2*MaxEntrySize)
+		if err := json.NewDecoder(body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("error: expected {\"data\": base64}"))
+			return
+		}
+		if len(req.Data) > MaxEntrySize {
+			writeError(w, http.StatusRequestEntityTooLarge, errors.New("error: entry too large"))
+			return
+		}
+
+		index, err := l.Append(req.Data)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, &addResponse{index, LeafHash(req.Data)})
+	}))
+
+	mux.HandleFunc("/entries/", method(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+		index, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/entries/"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("error: invalid index"))
+			return
+		}
+		entry, err := l.Entry(index)
+		if err != nil {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, &entryResponse{index, entry})
+	}))
+
+	mux.HandleFunc("/head", method(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, l.Head())
+	}))
+
+	mux.HandleFunc("/key", method(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, &keyResponse{"ed25519", l.PublicKey()})
+	}))
+
+	mux.HandleFunc("/proof/inclusion", method(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		size, err := intParam(query.Get("size"), l.Head().TreeSize)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		var index int
+		if hash := query.Get("hash"); hash != "" {
+			leaf, err := hex.DecodeString(hash)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, errors.New("error: invalid hash"))
+				return
+			}
+			var ok bool
+			if index, ok = l.IndexOf(leaf); !ok {
+				writeError(w, http.StatusNotFound, errors.New("error: no entry with leaf hash "+hash))
+				return
+			}
+		} else if index, err = intParam(query.Get("index"), -1); err != nil || index < 0 {
+			writeError(w, http.StatusBadRequest, errors.New("error: expected index or hash"))
+			return
+		}
+
+		path, err := l.InclusionProof(index, size)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		entry, _ := l.Entry(index)
+		writeJSON(w, http.StatusOK, &inclusionResponse{index, size, LeafHash(entry), nonNil(path)})
+	}))
+
+	mux.HandleFunc("/proof/consistency", method(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		first, err := intParam(query.Get("first"), -1)
+		if err != nil || first < 0 {
+			writeError(w, http.StatusBadRequest, errors.New("error: expected first"))
+			return
+		}
+		second, err := intParam(query.Get("second"), l.Head().TreeSize)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		proof, err := l.ConsistencyProof(first, second)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, &consistencyResponse{first, second, nonNil(proof)})
+	}))
+
+	return mux
+}
+
+// method rejects the requests with another method than the one of handler.
+func method(name string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != name {
+			w.Header().Set("Allow", name)
+			writeError(w, http.StatusMethodNotAllowed, errors.New("error: expected "+name))
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// intParam parses a query parameter, def when it is missing.
+func intParam(value string, def int) (int, error) {
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errors.New("error: invalid number " + value)
+	}
+	return n, nil
+}
+
+// nonNil makes empty proofs [] rather than null in JSON.
+func nonNil(proof [][]byte) [][]byte {
+	if proof == nil {
+		return [][]byte{}
+	}
+	return proof
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, &errorResponse{err.Error()})
+}
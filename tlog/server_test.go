@@ -0,0 +1,162 @@
+package tlog
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func newTestServer(t *testing.T) (*httptest.Server, *Log) {
+	l, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal("Expected no error, got " + err.Error())
+	}
+	server := httptest.NewServer(NewHandler(l))
+	t.Cleanup(func() {
+		server.Close()
+		l.Close()
+	})
+	return server, l
+}
+
+func get(t *testing.T, url string, status int, body interface{}) {
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal("Expected no error, got " + err.Error())
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != status {
+		t.Error("Expected status " + strconv.Itoa(status) + " for " + url + ", got " + strconv.Itoa(resp.StatusCode))
+	}
+	if body != nil {
+		json.NewDecoder(resp.Body).Decode(body)
+	}
+}
+
+func post(t *testing.T, url string, data []byte) *addResponse {
+	request, _ := json.Marshal(&addRequest{data})
+	resp, err := http.Post(url+"/entries", "application/json", bytes.NewReader(request))
+	if err != nil {
+		t.Fatal("Expected no error, got " + err.Error())
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Error("Expected status 201, got " + strconv.Itoa(resp.StatusCode))
+	}
+	var added addResponse
+	json.NewDecoder(resp.Body).Decode(&added)
+	return &added
+}
+
+func TestServerAppend(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	for i := 0; i < 3; i++ {
+		added := post(t, server.URL, []byte("entry "+strconv.Itoa(i)))
+		if added.Index != i {
+			t.Error("Expected index " + strconv.Itoa(i) + ", got " + strconv.Itoa(added.Index))
+		}
+	}
+
+	var entry entryResponse
+	get(t, server.URL+"/entries/1", http.StatusOK, &entry)
+	if string(entry.Data) != "entry 1" {
+		t.Error("Expected entry 1, got " + string(entry.Data))
+	}
+
+	get(t, server.URL+"/entries/3", http.StatusNotFound, nil)
+	get(t, server.URL+"/entries/x", http.StatusBadRequest, nil)
+}
+
+func TestServerSignedHead(t *testing.T) {
+	server, _ := newTestServer(t)
+	post(t, server.URL, []byte("A"))
+	post(t, server.URL, []byte("B"))
+
+	var key keyResponse
+	get(t, server.URL+"/key", http.StatusOK, &key)
+
+	var head SignedHead
+	get(t, server.URL+"/head", http.StatusOK, &head)
+	if head.TreeSize != 2 {
+		t.Error("Expected tree size 2, got " + strconv.Itoa(head.TreeSize))
+	}
+
+	if !VerifyHead(key.PublicKey, &head) {
+		t.Error("Expected valid signature of the head")
+	}
+}
+
+func TestServerInclusionProof(t *testing.T) {
+	server, _ := newTestServer(t)
+	var leaves [][]byte
+	for i := 0; i < 6; i++ {
+		leaves = append(leaves, post(t, server.URL, []byte("entry "+strconv.Itoa(i))).LeafHash)
+	}
+
+	var head SignedHead
+	get(t, server.URL+"/head", http.StatusOK, &head)
+
+	var byIndex inclusionResponse
+	get(t, server.URL+"/proof/inclusion?index=4", http.StatusOK, &byIndex)
+	if !VerifyInclusion(byIndex.LeafHash, 4, head.TreeSize, byIndex.AuditPath, head.RootHash) {
+		t.Error("Expected valid inclusion proof by index")
+	}
+
+	var byHash inclusionResponse
+	get(t, server.URL+"/proof/inclusion?hash="+hex.EncodeToString(leaves[2]), http.StatusOK, &byHash)
+	if byHash.Index != 2 || !VerifyInclusion(leaves[2], 2, head.TreeSize, byHash.AuditPath, head.RootHash) {
+		t.Error("Expected valid inclusion proof by hash, got index " + strconv.Itoa(byHash.Index))
+	}
+
+	get(t, server.URL+"/proof/inclusion?hash="+hex.EncodeToString(LeafHash([]byte("missing"))), http.StatusNotFound, nil)
+	get(t, server.URL+"/proof/inclusion?index=6", http.StatusBadRequest, nil)
+	get(t, server.URL+"/proof/inclusion?index=0&size=7", http.StatusBadRequest, nil)
+	get(t, server.URL+"/proof/inclusion", http.StatusBadRequest, nil)
+}
+
+func TestServerConsistencyProof(t *testing.T) {
+	server, _ := newTestServer(t)
+	for i := 0; i < 3; i++ {
+		post(t, server.URL, []byte("entry "+strconv.Itoa(i)))
+	}
+	var old SignedHead
+	get(t, server.URL+"/head", http.StatusOK, &old)
+
+	for i := 3; i < 10; i++ {
+		post(t, server.URL, []byte("entry "+strconv.Itoa(i)))
+	}
+	var head SignedHead
+	get(t, server.URL+"/head", http.StatusOK, &head)
+
+	var proof consistencyResponse
+	get(t, server.URL+"/proof/consistency?first=3&second=10", http.StatusOK, &proof)
+	if !VerifyConsistency(old.TreeSize, head.TreeSize, old.RootHash, head.RootHash, proof.Consistency) {
+		t.Error("Expected valid consistency proof")
+	}
+
+	get(t, server.URL+"/proof/consistency?first=11", http.StatusBadRequest, nil)
+	get(t, server.URL+"/proof/consistency", http.StatusBadRequest, nil)
+}
+
+func TestServerRejectsInvalidRequests(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	resp, _ := http.Post(server.URL+"/entries", "application/json", bytes.NewReader([]byte("not json")))
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Error("Expected status 400, got " + strconv.Itoa(resp.StatusCode))
+	}
+
+	resp, _ = http.Post(server.URL+"/head", "application/json", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Error("Expected status 405, got " + strconv.Itoa(resp.StatusCode))
+	}
+}
@@ -0,0 +1,203 @@
+package tlog
+
+import (
+	"bytes"
+	"errors"
+
+	. "github.com/SimoneStefani/thesis-algorithms/structures/common"
+	"github.com/SimoneStefani/thesis-algorithms/structures/mt"
+)
+
+// The tree of the log is a Merkle tree of mt hashed like RFC 6962: a leaf
+// hashes its entry after a zero byte and a node the digests of its children
+// after a one byte, so that a leaf can't be passed off as a node. The last
+// node of an odd level is promoted rather than paired with itself, so that
+// the perfect subtrees of a tree are nodes of every larger tree: the proofs
+// for an older size are made of them, see subtree.
+
+// LeafHash returns the digest of an entry.
+func LeafHash(data []byte) []byte {
+	h := Sum256(append([]byte{0}, data...))
+	return h[:]
+}
+
+func nodeHash(left []byte, right []byte) []byte {
+	buffer := make([]byte, 0, 1+2*HashSize)
+	buffer = append(buffer, 1)
+	buffer = append(buffer, left...)
+	h := Sum256(append(buffer, right...))
+	return h[:]
+}
+
+// hasher builds the trees of the logs with mt, the entries being the
+// transactions.
+var hasher = mt.Hasher{
+	Leaf: func(entry string) string {
+		return EncodeHash(LeafHash([]byte(entry)))
+	},
+	Combine: func(left string, right string) string {
+		l, _ := DecodeHash(left)
+		r, _ := DecodeHash(right)
+		return EncodeHash(nodeHash(l, r))
+	},
+	Promote: true,
+}
+
+// emptyRoot is the root of the tree without entries, the digest of nothing.
+func emptyRoot() []byte {
+	h := Sum256(nil)
+	return h[:]
+}
+
+// largestPowerOfTwo returns the largest power of two smaller than n > 1.
+func largestPowerOfTwo(n int) int {
+	k := 1
+	for k<<1 < n {
+		k <<= 1
+	}
+	return k
+}
+
+// subtree returns the root of the leaves [start, end) of t, end > start.
+// A perfect subtree is a node of t, the others are the right edges of the
+// smaller trees and are joined again from perfect ones.
+func subtree(t *mt.MerkleTree, start int, end int) []byte {
+	n := end - start
+	if n&(n-1) == 0 && start%n == 0 {
+		raw, _ := DecodeHash(t.Subtree(start, n).Hash())
+		return raw
+	}
+
+	k := largestPowerOfTwo(n)
+	return nodeHash(subtree(t, start, start+k), subtree(t, start+k, end))
+}
+
+// root returns the root of the first size leaves of t.
+func root(t *mt.MerkleTree, size int) []byte {
+	if size == 0 {
+		return emptyRoot()
+	}
+	if size == t.Length() {
+		raw, _ := DecodeHash(t.MerkleRoot())
+		return raw
+	}
+	return subtree(t, 0, size)
+}
+
+// inclusion returns the audit path of leaf index in the tree of the leaves
+// [start, end) of t, from the leaf up.
+func inclusion(t *mt.MerkleTree, index int, start int, end int) [][]byte {
+	n := end - start
+	if n == 1 {
+		return nil
+	}
+
+	k := largestPowerOfTwo(n)
+	if index-start < k {
+		return append(inclusion(t, index, start, start+k), subtree(t, start+k, end))
+	}
+	return append(inclusion(t, index, start+k, end), subtree(t, start, start+k))
+}
+
+// consistency returns the proof that the tree of the first m leaves of
+// [start, end) of t is a prefix of it. complete tells whether the subtree
+// of the m leaves is a node of the old tree, whose root is then already
+// known.
+func consistency(t *mt.MerkleTree, m int, start int, end int, complete bool) [][]byte {
+	n := end - start
+	if m == n {
+		if complete {
+			return nil
+		}
+		return [][]byte{subtree(t, start, end)}
+	}
+
+	k := largestPowerOfTwo(n)
+	if m <= k {
+		return append(consistency(t, m, start, start+k, complete), subtree(t, start+k, end))
+	}
+	return append(consistency(t, m-k, start+k, end, false), subtree(t, start, start+k))
+}
+
+// VerifyInclusion tells whether proof is the audit path of the leaf digest
+// at index in the tree of size leaves with the given root.
+func VerifyInclusion(leaf []byte, index int, size int, proof [][]byte, root []byte) bool {
+	if index < 0 || index >= size {
+		return false
+	}
+
+	// walk up from the leaf: the siblings are on the right while the node
+	// is a left child of a node with two children, on the left otherwise
+	fn, sn := index, size-1
+	hash := leaf
+	for _, sibling := range proof {
+		if sn == 0 {
+			return false
+		}
+		if fn%2 == 1 || fn == sn {
+			hash = nodeHash(sibling, hash)
+			for fn%2 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			hash = nodeHash(hash, sibling)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	return sn == 0 && bytes.Equal(hash, root)
+}
+
+// VerifyConsistency tells whether proof shows that the tree of first leaves
+// with firstRoot is a prefix of the tree of second leaves with secondRoot,
+// following RFC 9162.
+func VerifyConsistency(first int, second int, firstRoot []byte, secondRoot []byte, proof [][]byte) bool {
+	if first < 0 || first > second {
+		return false
+	}
+	if first == second {
+		return len(proof) == 0 && bytes.Equal(firstRoot, secondRoot)
+	}
+	if first == 0 {
+		// the empty tree is a prefix of every tree
+		return len(proof) == 0
+	}
+
+	// the old root is the first node of the proof unless it is a perfect
+	// subtree of the new tree
+	if first&(first-1) == 0 {
+		proof = append([][]byte{firstRoot}, proof...)
+	}
+	if len(proof) == 0 {
+		return false
+	}
+
+	fn, sn := first-1, second-1
+	for fn%2 == 1 {
+		fn >>= 1
+		sn >>= 1
+	}
+
+	fr, sr := proof[0], proof[0]
+	for _, c := range proof[1:] {
+		if sn == 0 {
+			return false
+		}
+		if fn%2 == 1 || fn == sn {
+			fr = nodeHash(c, fr)
+			sr = nodeHash(c, sr)
+			for fn%2 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			sr = nodeHash(sr, c)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	return sn == 0 && bytes.Equal(fr, firstRoot) && bytes.Equal(sr, secondRoot)
+}
+
+var errRange = errors.New("error: tree size out of range")
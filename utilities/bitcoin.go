@@ -0,0 +1,356 @@
+package utilities
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/SimoneStefani/thesis-algorithms/structures/mt"
+)
+
+// BlockHeaderSize is the size of a serialized Bitcoin block header.
+const BlockHeaderSize = 80
+
+// MaxBlockSize bounds the serialized size of a block by the block weight
+// limit, reached by a block of witness data only.
+const MaxBlockSize = 4000000
+
+// networkMagics start every block of the blk*.dat files written by Bitcoin
+// Core: mainnet, testnet3, signet and regtest.
+var networkMagics = [][]byte{
+	{0xf9, 0xbe, 0xb4, 0xd9},
+	{0x0b, 0x11, 0x09, 0x07},
+	{0x0a, 0x03, 0xcf, 0x40},
+	{0xfa, 0xbf, 0xb5, 0xda},
+}
+
+// Block is a raw Bitcoin block: its 80 bytes header and its transactions as
+// serialized on the network, witness included, with their txids. Digests
+// are in the byte order they are hashed in, the reverse of the one they are
+// displayed in, see DisplayHash.
+type Block struct {
+	Header       []byte
+	Transactions [][]byte
+	TxIDs        [][]byte
+}
+
+// DoubleSHA256 is the digest of the transactions, blocks and Merkle trees of
+// Bitcoin.
+func DoubleSHA256(data []byte) []byte {
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	return second[:]
+}
+
+// DisplayHash returns the hex of a digest in the byte order of block
+// explorers and Bitcoin Core, reversed.
+func DisplayHash(digest []byte) string {
+	reversed := make([]byte, len(digest))
+	for i, b := range digest {
+		reversed[len(digest)-1-i] = b
+	}
+	return hex.EncodeToString(reversed)
+}
+
+// Hash returns the digest of the header, which identifies the block.
+func (b *Block) Hash() []byte {
+	return DoubleSHA256(b.Header)
+}
+
+// HeaderMerkleRoot returns the Merkle root committed to by the header.
+func (b *Block) HeaderMerkleRoot() []byte {
+	return b.Header[36:68]
+}
+
+// MerkleRoot computes the Merkle root of the txids with the Bitcoin mode of
+// mt.
+func (b *Block) MerkleRoot() ([]byte, error) {
+	tree, err := mt.NewBitcoinTree(b.TxIDs)
+	if err != nil {
+		return nil, err
+	}
+	return tree.RawRoot(), nil
+}
+
+// CheckMerkleRoot tells whether the Merkle root of the transactions is the
+// one committed to by the header.
+func (b *Block) CheckMerkleRoot() (bool, error) {
+	root, err := b.MerkleRoot()
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(root, b.HeaderMerkleRoot()), nil
+}
+
+// ParseBlock parses a single raw block.
+func ParseBlock(data []byte) (*Block, error) {
+	r := bufio.NewReader(bytes.NewReader(data))
+	block, err := readBlock(r)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.Peek(1); err != io.EOF {
+		return nil, errors.New("error: trailing bytes after the block")
+	}
+	return block, nil
+}
+
+// MerkleBlock returns the proof that the transactions at the given indices
+// are part of the block, laid out like the merkleblock messages of BIP 37:
+// the header followed by the partial Merkle tree of the txids.
+func (b *Block) MerkleBlock(indices ...int) ([]byte, error) {
+	tree, err := mt.NewBitcoinTree(b.TxIDs)
+	if err != nil {
+		return nil, err
+	}
+	partial, err := tree.PartialMerkleTree(indices...)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte(nil), b.Header...), partial...), nil
+}
+
+// CheckMerkleBlock checks a merkleblock returned by MerkleBlock against the
+// merkle root of its header and returns the header, and the txids it
+// proves with their indices in the block.
+func CheckMerkleBlock(data []byte) ([]byte, [][]byte, []int, error) {
+	if len(data) < BlockHeaderSize {
+		return nil, nil, nil, errors.New("error: truncated block header")
+	}
+	header := data[:BlockHeaderSize]
+
+	root, txids, indices, err := mt.CheckPartialMerkleTree(data[BlockHeaderSize:])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if !bytes.Equal(root, header[36:68]) {
+		return nil, nil, nil, errors.New("error: the merkleblock does not match the merkle root of its header")
+	}
+	return header, txids, indices, nil
+}
+
+// ReadBlocks reads the transactions of every block of r, see
+// ReadBlockFile.
+func ReadBlocks(r io.Reader) ([][]byte, error) {
+	blocks, err := ReadBlockFile(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var transactions [][]byte
+	for _, block := range blocks {
+		transactions = append(transactions, block.Transactions...)
+	}
+	return transactions, nil
+}
+
+// ReadBlockFile reads every block of r, either blocks one after the other
+// or a blk*.dat file where each block is preceded by the network magic and
+// its size.
+func ReadBlockFile(r io.Reader) ([]*Block, error) {
+	br := bufio.NewReader(r)
+
+	var blocks []*Block
+	for {
+		prefix, err := br.Peek(4)
+		if err == io.EOF && len(prefix) == 0 {
+			return blocks, nil
+		}
+
+		var block *Block
+		if isNetworkMagic(prefix) {
+			var size uint32
+			br.Discard(4)
+			if err := binary.Read(br, binary.LittleEndian, &size); err != nil {
+				return nil, errors.New("error: truncated block size")
+			}
+			if size > MaxBlockSize {
+				return nil, errors.New("error: block larger than the block weight limit")
+			}
+			data := make([]byte, size)
+			if _, err := io.ReadFull(br, data); err != nil {
+				return nil, errors.New("error: truncated block")
+			}
+			block, err = ParseBlock(data)
+		} else if bytes.Equal(prefix, []byte{0, 0, 0, 0}) {
+			// the unused tail of a preallocated blk*.dat file
+			return blocks, nil
+		} else {
+			block, err = readBlock(br)
+		}
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+	}
+}
+
+func isNetworkMagic(prefix []byte) bool {
+	for _, magic := range networkMagics {
+		if bytes.Equal(prefix, magic) {
+			return true
+		}
+	}
+	return false
+}
+
+func readBlock(r *bufio.Reader) (*Block, error) {
+	header := make([]byte, BlockHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, errors.New("error: truncated block header")
+	}
+
+	count, err := readCompactSize(r)
+	if err != nil {
+		return nil, errors.New("error: truncated transaction count")
+	}
+
+	block := &Block{Header: header}
+	for i := uint64(0); i < count; i++ {
+		tx, stripped, err := readTransaction(r)
+		if err != nil {
+			return nil, fmt.Errorf("error: transaction %d: %v", i, err)
+		}
+		block.Transactions = append(block.Transactions, tx)
+		block.TxIDs = append(block.TxIDs, DoubleSHA256(stripped))
+	}
+	return block, nil
+}
+
+// txReader reads a transaction and records its bytes, and apart from them
+// the bytes of the serialization without witness, which the txid hashes.
+type txReader struct {
+	r        *bufio.Reader
+	raw      []byte
+	stripped []byte
+	witness  bool
+	err      error
+}
+
+func (t *txReader) bytes(n uint64) []byte {
+	if t.err != nil {
+		return nil
+	}
+	if n > MaxBlockSize {
+		t.err = errors.New("field too large")
+		return nil
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(t.r, data); err != nil {
+		t.err = errors.New("truncated transaction")
+		return nil
+	}
+	t.raw = append(t.raw, data...)
+	if !t.witness {
+		t.stripped = append(t.stripped, data...)
+	}
+	return data
+}
+
+// uint reads a little endian integer of n bytes.
+func (t *txReader) uint(n uint64) uint64 {
+	data := t.bytes(n)
+	if t.err != nil {
+		return 0
+	}
+	var buf [8]byte
+	copy(buf[:], data)
+	return binary.LittleEndian.Uint64(buf[:])
+}
+
+func (t *txReader) compactSize() uint64 {
+	switch first := t.uint(1); first {
+	case 0xfd:
+		return t.uint(2)
+	case 0xfe:
+		return t.uint(4)
+	case 0xff:
+		return t.uint(8)
+	default:
+		return first
+	}
+}
+
+// readTransaction reads a transaction field by field, since its size is not
+// serialized: the version, the segwit marker and flag if any, the inputs,
+// the outputs, the witnesses of the inputs if any and the lock time. It
+// returns the transaction and its serialization without witness.
+func readTransaction(r *bufio.Reader) ([]byte, []byte, error) {
+	t := &txReader{r: r}
+
+	t.bytes(4)
+
+	// the marker replaces the input count, the flag must be 1
+	witness := false
+	if marker, err := r.Peek(1); err == nil && marker[0] == 0 {
+		t.witness = true
+		t.bytes(1)
+		if flag := t.uint(1); t.err == nil && flag != 1 {
+			return nil, nil, errors.New("invalid segwit flag")
+		}
+		t.witness = false
+		witness = true
+	}
+	inputs := t.compactSize()
+
+	for i := uint64(0); i < inputs && t.err == nil; i++ {
+		t.bytes(36)
+		t.bytes(t.compactSize())
+		t.bytes(4)
+	}
+
+	outputs := t.compactSize()
+	for i := uint64(0); i < outputs && t.err == nil; i++ {
+		t.bytes(8)
+		t.bytes(t.compactSize())
+	}
+
+	if witness {
+		t.witness = true
+		for i := uint64(0); i < inputs && t.err == nil; i++ {
+			items := t.compactSize()
+			for j := uint64(0); j < items && t.err == nil; j++ {
+				t.bytes(t.compactSize())
+			}
+		}
+		t.witness = false
+	}
+
+	t.bytes(4)
+	if t.err != nil {
+		return nil, nil, t.err
+	}
+	return t.raw, t.stripped, nil
+}
+
+// readCompactSize reads the variable length integers of the Bitcoin
+// serialization.
+func readCompactSize(r io.Reader) (uint64, error) {
+	var first [1]byte
+	if _, err := io.ReadFull(r, first[:]); err != nil {
+		return 0, err
+	}
+
+	var size int
+	switch first[0] {
+	case 0xfd:
+		size = 2
+	case 0xfe:
+		size = 4
+	case 0xff:
+		size = 8
+	default:
+		return uint64(first[0]), nil
+	}
+
+	var buf [8]byte
+	if _, err := io.ReadFull(r, buf[:size]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(buf[:]), nil
+}
@@ -0,0 +1,195 @@
+package utilities
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"encoding/hex"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// The fixtures are the raw genesis block, the header and txids of block
+// 100000 whose four transactions exercise the inner nodes of the tree, and
+// the raw block 277647 with 213 transactions in the blk*.dat layout, as
+// shipped compressed in the test data of btcd.
+func loadHex(t *testing.T, path string) []byte {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := hex.DecodeString(strings.TrimSpace(string(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+// displayed parses a digest written in the displayed byte order.
+func displayed(hash string) []byte {
+	raw, _ := hex.DecodeString(hash)
+	for i, j := 0, len(raw)-1; i < j; i, j = i+1, j-1 {
+		raw[i], raw[j] = raw[j], raw[i]
+	}
+	return raw
+}
+
+func TestParseGenesisBlock(t *testing.T) {
+	block, err := ParseBlock(loadHex(t, "testdata/genesis.hex"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hash := DisplayHash(block.Hash()); hash != "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f" {
+		t.Error("Expected the genesis block hash, got " + hash)
+	}
+
+	if len(block.TxIDs) != 1 {
+		t.Fatal("Expected 1 transaction, got " + strconv.Itoa(len(block.TxIDs)))
+	}
+
+	if txid := DisplayHash(block.TxIDs[0]); txid != "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b" {
+		t.Error("Expected the coinbase txid, got " + txid)
+	}
+
+	if valid, err := block.CheckMerkleRoot(); err != nil || !valid {
+		t.Error("Expected the merkle root of the header")
+	}
+}
+
+func TestMerkleRootOfBlock100000(t *testing.T) {
+	block := &Block{Header: loadHex(t, "testdata/block100000_header.hex")}
+
+	if hash := DisplayHash(block.Hash()); hash != "000000000003ba27aa200b1cecaad478d2b00432346c3f1f3986da1afd33e506" {
+		t.Error("Expected the hash of block 100000, got " + hash)
+	}
+
+	txids, _ := LoadData("testdata/block100000_txids.txt")
+	for _, txid := range txids {
+		block.TxIDs = append(block.TxIDs, displayed(txid))
+	}
+
+	root, err := block.MerkleRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if DisplayHash(root) != "f3e94742aca4b5ef85488dc37c06c3282295ffec960994b2c0d5ac2a25a95766" {
+		t.Error("Expected the merkle root of block 100000, got " + DisplayHash(root))
+	}
+
+	if valid, _ := block.CheckMerkleRoot(); !valid {
+		t.Error("Expected the merkle root of the header")
+	}
+
+	// a different order of the transactions commits to a different root
+	block.TxIDs[1], block.TxIDs[2] = block.TxIDs[2], block.TxIDs[1]
+	if valid, _ := block.CheckMerkleRoot(); valid {
+		t.Error("Expected a mismatch with reordered transactions")
+	}
+}
+
+func TestTxIDOfSegwitTransactionSkipsWitness(t *testing.T) {
+	stripped := "02000000" + "01" + strings.Repeat("11", 36) + "00" + "ffffffff" +
+		"01" + "0100000000000000" + "160014" + strings.Repeat("22", 20) + "00000000"
+	tx, _ := hex.DecodeString(stripped[:8] + "0001" + stripped[8:len(stripped)-8] + "02" + "03aabbcc" + "01dd" + "00000000")
+	want, _ := hex.DecodeString(stripped)
+
+	block, err := ParseBlock(testBlock([][]byte{tx}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(block.TxIDs[0], DoubleSHA256(want)) {
+		t.Error("Expected the txid of the transaction without witness")
+	}
+}
+
+func TestReadBlockFileKeepsBlocksApart(t *testing.T) {
+	genesis := loadHex(t, "testdata/genesis.hex")
+
+	blocks, err := ReadBlockFile(bytes.NewReader(append(append([]byte(nil), genesis...), genesis...)))
+
+	if err != nil || len(blocks) != 2 {
+		t.Fatal("Expected 2 blocks, got " + strconv.Itoa(len(blocks)))
+	}
+
+	if !bytes.Equal(blocks[1].Hash(), blocks[0].Hash()) {
+		t.Error("Expected the same block twice")
+	}
+}
+
+func TestReadBlockFileRejectsOversizedBlock(t *testing.T) {
+	// the mainnet magic and a size of 4 GiB
+	data := []byte{0xf9, 0xbe, 0xb4, 0xd9, 0xff, 0xff, 0xff, 0xff}
+
+	if _, err := ReadBlockFile(bytes.NewReader(data)); err == nil {
+		t.Error("Expected error for a block larger than MaxBlockSize")
+	}
+}
+
+func TestMerkleBlockOfBlock100000(t *testing.T) {
+	block := &Block{Header: loadHex(t, "testdata/block100000_header.hex")}
+	txids, _ := LoadData("testdata/block100000_txids.txt")
+	for _, txid := range txids {
+		block.TxIDs = append(block.TxIDs, displayed(txid))
+	}
+
+	merkleBlock, err := block.MerkleBlock(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header, proven, indices, err := CheckMerkleBlock(merkleBlock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(header, block.Header) || len(proven) != 1 || indices[0] != 2 {
+		t.Error("Expected the third transaction of block 100000")
+	}
+
+	if DisplayHash(proven[0]) != txids[2] {
+		t.Error("Expected txid " + txids[2] + ", got " + DisplayHash(proven[0]))
+	}
+
+	// a header committing to another root rejects the proof
+	merkleBlock[40] ^= 1
+	if _, _, _, err := CheckMerkleBlock(merkleBlock); err == nil {
+		t.Error("Expected error for a different merkle root")
+	}
+}
+
+func TestCheckMerkleRootOfBlock277647(t *testing.T) {
+	file, err := os.Open("testdata/block277647.dat.bz2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	blocks, err := ReadBlockFile(bzip2.NewReader(file))
+	if err != nil || len(blocks) != 1 {
+		t.Fatal("Expected 1 block, got " + strconv.Itoa(len(blocks)))
+	}
+	block := blocks[0]
+
+	if hash := DisplayHash(block.Hash()); hash != "0000000000000000054a714e580b16c583701712ab91060e92dbde6eb1e052a8" {
+		t.Error("Expected the hash of block 277647, got " + hash)
+	}
+
+	if len(block.TxIDs) != 213 {
+		t.Error("Expected 213 transactions, got " + strconv.Itoa(len(block.TxIDs)))
+	}
+
+	if valid, err := block.CheckMerkleRoot(); !valid || err != nil {
+		t.Error("Expected the computed root to match the header")
+	}
+
+	// a transaction in the last, odd, pair of the leaves
+	merkleBlock, _ := block.MerkleBlock(212)
+	header, txids, _, err := CheckMerkleBlock(merkleBlock)
+	if err != nil || !bytes.Equal(header, block.Header) || !bytes.Equal(txids[0], block.TxIDs[212]) {
+		t.Error("Expected a valid merkleblock of transaction 212")
+	}
+}
@@ -0,0 +1,123 @@
+package utilities
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math/rand"
+	"sort"
+)
+
+const alphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// GenerateData returns size elements of the given kind, drawn from a source
+// seeded with seed so that the same arguments always give the same data:
+// uniform -> random alphanumeric strings
+// sorted -> uniform strings in increasing order
+// reverse -> uniform strings in decreasing order
+// duplicates -> uniform strings where a fraction dup of the elements repeat
+// an earlier element
+// bitcoin -> hex encoded raw transactions with one to three P2PKH inputs and
+// outputs, whose length follows from their structure
+// The length of the strings is drawn uniformly between minLen and maxLen.
+func GenerateData(kind string, size int, minLen int, maxLen int, dup float64, seed int64) ([]string, error) {
+	if size < 0 {
+		return nil, errors.New("error: size must not be negative")
+	}
+	if minLen < 1 || maxLen < minLen {
+		return nil, errors.New("error: lengths must satisfy 1 <= minLen <= maxLen")
+	}
+	if dup < 0 || dup > 1 {
+		return nil, errors.New("error: duplicate fraction must be between 0 and 1")
+	}
+
+	r := rand.New(rand.NewSource(seed))
+	data := make([]string, size)
+
+	switch kind {
+	case "uniform", "sorted", "reverse", "duplicates":
+		for i := range data {
+			data[i] = randomString(r, minLen+r.Intn(maxLen-minLen+1))
+		}
+	case "bitcoin":
+		for i := range data {
+			data[i] = randomTransaction(r)
+		}
+		return data, nil
+	default:
+		return nil, errors.New("error: unknown dataset kind " + kind)
+	}
+
+	switch kind {
+	case "sorted":
+		sort.Strings(data)
+	case "reverse":
+		sort.Sort(sort.Reverse(sort.StringSlice(data)))
+	case "duplicates":
+		for i := 1; i < len(data); i++ {
+			if r.Float64() < dup {
+				data[i] = data[r.Intn(i)]
+			}
+		}
+	}
+
+	return data, nil
+}
+
+func randomString(r *rand.Rand, length int) string {
+	b := make([]byte, length)
+	for i := range b {
+		b[i] = alphabet[r.Intn(len(alphabet))]
+	}
+	return string(b)
+}
+
+// randomTransaction lays out a version 1 transaction: every input spends a
+// random outpoint with a signature and a compressed public key, every output
+// pays a random amount to a public key hash.
+func randomTransaction(r *rand.Rand) string {
+	var tx []byte
+	tx = binary.LittleEndian.AppendUint32(tx, 1)
+
+	inputs := 1 + r.Intn(3)
+	tx = append(tx, byte(inputs))
+	for i := 0; i < inputs; i++ {
+		tx = append(tx, randomBytes(r, 32)...)
+		tx = binary.LittleEndian.AppendUint32(tx, uint32(r.Intn(4)))
+
+		// DER signature with the SIGHASH_ALL byte, then the public key
+		sig := append([]byte{0x30, 0x45, 0x02, 0x21, 0x00}, randomBytes(r, 32)...)
+		sig = append(sig, 0x02, 0x20)
+		sig = append(sig, randomBytes(r, 32)...)
+		sig = append(sig, 0x01)
+		pubKey := append([]byte{byte(0x02 + r.Intn(2))}, randomBytes(r, 32)...)
+
+		script := append([]byte{byte(len(sig))}, sig...)
+		script = append(script, byte(len(pubKey)))
+		script = append(script, pubKey...)
+		tx = append(tx, byte(len(script)))
+		tx = append(tx, script...)
+		tx = binary.LittleEndian.AppendUint32(tx, 0xffffffff)
+	}
+
+	outputs := 1 + r.Intn(3)
+	tx = append(tx, byte(outputs))
+	for i := 0; i < outputs; i++ {
+		tx = binary.LittleEndian.AppendUint64(tx, uint64(r.Int63n(100000000000)))
+
+		// OP_DUP OP_HASH160 <20 bytes> OP_EQUALVERIFY OP_CHECKSIG
+		script := append([]byte{0x76, 0xa9, 0x14}, randomBytes(r, 20)...)
+		script = append(script, 0x88, 0xac)
+		tx = append(tx, byte(len(script)))
+		tx = append(tx, script...)
+	}
+
+	tx = binary.LittleEndian.AppendUint32(tx, 0)
+	return hex.EncodeToString(tx)
+}
+
+func randomBytes(r *rand.Rand, n int) []byte {
+	b := make([]byte, n)
+	r.Read(b)
+	return b
+}
@@ -0,0 +1,83 @@
+package utilities
+
+import (
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+func TestGenerateDataIsDeterministic(t *testing.T) {
+	a, _ := GenerateData("uniform", 100, 8, 16, 0, 42)
+	b, _ := GenerateData("uniform", 100, 8, 16, 0, 42)
+
+	for i := range a {
+		if a[i] != b[i] {
+			t.Error("Expected " + a[i] + ", got " + b[i])
+		}
+	}
+}
+
+func TestGenerateDataLengths(t *testing.T) {
+	data, _ := GenerateData("uniform", 1000, 8, 16, 0, 1)
+
+	if len(data) != 1000 {
+		t.Error("Expected 1000 elements, got " + strconv.Itoa(len(data)))
+	}
+
+	for _, tr := range data {
+		if len(tr) < 8 || len(tr) > 16 {
+			t.Error("Expected length between 8 and 16, got " + strconv.Itoa(len(tr)))
+		}
+	}
+}
+
+func TestGenerateDataOrder(t *testing.T) {
+	sorted, _ := GenerateData("sorted", 100, 8, 8, 0, 1)
+	reverse, _ := GenerateData("reverse", 100, 8, 8, 0, 1)
+
+	if !sort.StringsAreSorted(sorted) {
+		t.Error("Expected sorted data")
+	}
+
+	if reverse[0] != sorted[99] || reverse[99] != sorted[0] {
+		t.Error("Expected the reverse of the sorted data")
+	}
+}
+
+func TestGenerateDataDuplicates(t *testing.T) {
+	data, _ := GenerateData("duplicates", 1000, 32, 32, 0.5, 1)
+
+	seen := make(map[string]bool)
+	for _, tr := range data {
+		seen[tr] = true
+	}
+
+	if len(seen) > 600 || len(seen) < 400 {
+		t.Error("Expected about 500 distinct elements, got " + strconv.Itoa(len(seen)))
+	}
+}
+
+func TestGenerateDataBitcoinTransactions(t *testing.T) {
+	data, _ := GenerateData("bitcoin", 10, 1, 1, 0, 1)
+
+	for _, tr := range data {
+		raw, err := hex.DecodeString(tr)
+		if err != nil {
+			t.Error("Expected error nil, got " + err.Error())
+		}
+
+		// version 1 and a locktime of zero
+		if raw[0] != 1 || raw[len(raw)-1] != 0 {
+			t.Error("Expected a version 1 transaction, got " + tr)
+		}
+	}
+}
+
+func TestGenerateDataWithUnknownKind(t *testing.T) {
+	_, err := GenerateData("gaussian", 10, 1, 1, 0, 1)
+
+	if err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
@@ -1,70 +1,117 @@
 package utilities
 
 import (
-	"bufio"
-	"log"
+	"errors"
+	"io"
 	"os"
+	"path/filepath"
+	"sort"
 )
 
-func LoadData(path string) []string {
-	file, err := os.Open(path)
+// Stdio is the path standing for stdin as an input and for stdout as an
+// output.
+const Stdio = "-"
 
-	// file opening error logging
-	if err != nil {
-		log.Fatal(err)
+// ResolveInputs returns the files matching pattern, relative to dir unless
+// it is absolute, in lexical order. Stdio is returned as it is.
+func ResolveInputs(dir string, pattern string) ([]string, error) {
+	if pattern == Stdio {
+		return []string{Stdio}, nil
 	}
-	defer file.Close()
-
-	// load strings from file into slice
-	var data []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		data = append(data, scanner.Text())
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(dir, pattern)
 	}
 
-	// scanner parsing error logging
-	if err := scanner.Err(); err != nil {
-		log.Fatal(err)
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
 	}
-
-	return data
+	if len(paths) == 0 {
+		return nil, errors.New("error: no input file matches " + pattern)
+	}
+	sort.Strings(paths)
+	return paths, nil
 }
 
-func WriteData(path string, data string) {
+// LoadData reads the elements of the file at path, one per line.
+func LoadData(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
 
-	// detect if file exists
-	var _, e = os.Stat(path)
+	return ReadData(file)
+}
 
-	// remove file if it exists
-	if os.IsExist(e) {
-		var e = os.Remove(path)
-		if e != nil {
-			log.Fatal(e)
+// LoadInput reads the elements of the file at path, or of stdin when path is
+// Stdio, in the given format, see ReadInput, as the leaves of the structures.
+func LoadInput(path string, format string, maxLineSize int) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != Stdio {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
 		}
+		defer file.Close()
+		r = file
 	}
 
-	// create file
-	var file, err = os.Create(path)
+	elements, err := ReadInput(r, format, maxLineSize)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	defer file.Close()
+	return Leaves(elements), nil
+}
 
-	file, err = os.OpenFile(path, os.O_RDWR, 0644)
+// ReadData reads the elements from r, one per line of at most
+// DefaultMaxLineSize bytes.
+func ReadData(r io.Reader) ([]string, error) {
+	lines, err := ReadLines(r, DefaultMaxLineSize)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	defer file.Close()
+	return Leaves(lines), nil
+}
 
-	// write the results
-	_, err = file.WriteString(data)
-	if err != nil {
-		log.Fatal(err)
+// WriteData replaces the file at path with data, creating its folder if
+// needed. The data is written to a temporary file in the same folder which
+// is renamed over path, so that readers see either the old or the new file
+// and never a partial one.
+func WriteData(path string, data string) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
 	}
 
-	// save changes
-	err = file.Sync()
+	file, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp")
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+	// the temporary file is gone once renamed, so this only cleans up
+	// after a failure
+	defer os.Remove(file.Name())
+
+	if err := WriteDataTo(file, data); err != nil {
+		file.Close()
+		return err
 	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(file.Name(), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
+
+// WriteDataTo writes data to w.
+func WriteDataTo(w io.Writer, data string) error {
+	_, err := io.WriteString(w, data)
+	return err
 }
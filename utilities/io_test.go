@@ -0,0 +1,101 @@
+package utilities
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestLoadDataMissingFile(t *testing.T) {
+	_, err := LoadData(filepath.Join(t.TempDir(), "missing.txt"))
+
+	if err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
+
+func TestReadDataOnePerLine(t *testing.T) {
+	data, err := ReadData(strings.NewReader("A\nB\nC\n"))
+
+	if err != nil {
+		t.Error("Expected no error, got " + err.Error())
+	}
+
+	if len(data) != 3 || data[2] != "C" {
+		t.Error("Expected 3 elements, got " + strconv.Itoa(len(data)))
+	}
+}
+
+func TestWriteDataCreatesFolder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results", "result.txt")
+
+	if err := WriteData(path, "A\nB"); err != nil {
+		t.Error("Expected no error, got " + err.Error())
+	}
+
+	data, err := LoadData(path)
+	if err != nil || len(data) != 2 {
+		t.Error("Expected 2 elements, got " + strconv.Itoa(len(data)))
+	}
+}
+
+func TestWriteDataReplacesFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "result.txt")
+
+	WriteData(path, "a much longer first content")
+	if err := WriteData(path, "short"); err != nil {
+		t.Error("Expected no error, got " + err.Error())
+	}
+
+	content, _ := os.ReadFile(path)
+	if string(content) != "short" {
+		t.Error("Expected short, got " + string(content))
+	}
+
+	// no temporary file is left behind
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Error("Expected 1 file, got " + strconv.Itoa(len(entries)))
+	}
+}
+
+func TestWriteDataIntoFileFails(t *testing.T) {
+	dir := t.TempDir()
+	WriteData(filepath.Join(dir, "file"), "")
+
+	if err := WriteData(filepath.Join(dir, "file", "result.txt"), "A"); err == nil {
+		t.Error("Expected error when the folder is a file")
+	}
+}
+
+func TestResolveInputsMatchesGlob(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.txt", "a.txt", "c.csv"} {
+		WriteData(filepath.Join(dir, name), "A")
+	}
+
+	paths, err := ResolveInputs(dir, "*.txt")
+
+	if err != nil {
+		t.Error("Expected no error, got " + err.Error())
+	}
+
+	if len(paths) != 2 || filepath.Base(paths[0]) != "a.txt" {
+		t.Error("Expected a.txt and b.txt, got " + strings.Join(paths, ", "))
+	}
+
+	if _, err := ResolveInputs(dir, "*.json"); err == nil {
+		t.Error("Expected error when no file matches")
+	}
+}
+
+func TestResolveInputsStdio(t *testing.T) {
+	paths, _ := ResolveInputs("source", Stdio)
+
+	if len(paths) != 1 || paths[0] != Stdio {
+		t.Error("Expected stdin, got " + strings.Join(paths, ", "))
+	}
+}
@@ -0,0 +1,132 @@
+package utilities
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// DefaultMaxLineSize is the longest line read by default, enough for the
+// largest serialized Bitcoin transaction in hex.
+const DefaultMaxLineSize = 8 * 1024 * 1024
+
+// InputFormats are the formats understood by ReadInput:
+// text -> one element per line (default)
+// hex -> one hex encoded element per line
+// base64 -> one base64 encoded element per line
+// prefixed -> binary elements, each preceded by its length as a 4 bytes big
+// endian integer
+// block -> raw Bitcoin blocks, whose transactions are the elements
+var InputFormats = []string{"text", "hex", "base64", "prefixed", "block"}
+
+// ReadInput reads the elements from r in the given format. Lines longer than
+// maxLineSize are an error, zero stands for DefaultMaxLineSize.
+func ReadInput(r io.Reader, format string, maxLineSize int) ([][]byte, error) {
+	switch format {
+	case "text", "":
+		return ReadLines(r, maxLineSize)
+	case "hex":
+		return ReadHexLines(r, maxLineSize)
+	case "base64":
+		return ReadBase64Lines(r, maxLineSize)
+	case "prefixed":
+		return ReadPrefixed(r)
+	case "block":
+		return ReadBlocks(r)
+	}
+	return nil, errors.New("error: unknown input format " + format)
+}
+
+// Leaves converts elements to the strings the structures are built from.
+// Go strings hold arbitrary bytes, so binary elements are kept as they are.
+func Leaves(elements [][]byte) []string {
+	leaves := make([]string, len(elements))
+	for i, element := range elements {
+		leaves[i] = string(element)
+	}
+	return leaves
+}
+
+// ReadLines reads the elements from r, one per line without the line ending.
+func ReadLines(r io.Reader, maxLineSize int) ([][]byte, error) {
+	if maxLineSize <= 0 {
+		maxLineSize = DefaultMaxLineSize
+	}
+
+	var lines [][]byte
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, min(64*1024, maxLineSize)), maxLineSize)
+	for scanner.Scan() {
+		lines = append(lines, append([]byte(nil), scanner.Bytes()...))
+	}
+	if err := scanner.Err(); err != nil {
+		if err == bufio.ErrTooLong {
+			return nil, fmt.Errorf("error: line %d is longer than %d bytes", len(lines)+1, maxLineSize)
+		}
+		return nil, err
+	}
+	return lines, nil
+}
+
+// ReadHexLines reads one hex encoded element per line. Blank lines are
+// skipped.
+func ReadHexLines(r io.Reader, maxLineSize int) ([][]byte, error) {
+	return decodeLines(r, maxLineSize, hex.DecodeString)
+}
+
+// ReadBase64Lines reads one standard base64 encoded element per line. Blank
+// lines are skipped.
+func ReadBase64Lines(r io.Reader, maxLineSize int) ([][]byte, error) {
+	return decodeLines(r, maxLineSize, base64.StdEncoding.DecodeString)
+}
+
+func decodeLines(r io.Reader, maxLineSize int, decode func(string) ([]byte, error)) ([][]byte, error) {
+	lines, err := ReadLines(r, maxLineSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var elements [][]byte
+	for i, line := range lines {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		element, err := decode(string(line))
+		if err != nil {
+			return nil, fmt.Errorf("error: line %d: %v", i+1, err)
+		}
+		elements = append(elements, element)
+	}
+	return elements, nil
+}
+
+// ReadPrefixed reads binary elements, each preceded by its length as a 4
+// bytes big endian integer, until the end of r.
+func ReadPrefixed(r io.Reader) ([][]byte, error) {
+	br := bufio.NewReader(r)
+
+	var elements [][]byte
+	var prefix [4]byte
+	for {
+		if _, err := io.ReadFull(br, prefix[:]); err == io.EOF {
+			return elements, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("error: truncated length of element %d", len(elements)+1)
+		}
+
+		// copy rather than allocate the announced length up front, so that a
+		// corrupt prefix can't claim gigabytes the input doesn't hold
+		size := int64(binary.BigEndian.Uint32(prefix[:]))
+		var element bytes.Buffer
+		if _, err := io.CopyN(&element, br, size); err != nil {
+			return nil, fmt.Errorf("error: truncated element %d", len(elements)+1)
+		}
+		elements = append(elements, element.Bytes())
+	}
+}
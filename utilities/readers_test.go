@@ -0,0 +1,162 @@
+package utilities
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestReadLinesLongerThanScannerDefault(t *testing.T) {
+	long := strings.Repeat("a", 100*1024)
+
+	lines, err := ReadLines(strings.NewReader(long+"\nb\n"), 0)
+
+	if err != nil {
+		t.Error("Expected no error, got " + err.Error())
+	}
+
+	if len(lines) != 2 || len(lines[0]) != len(long) {
+		t.Error("Expected 2 lines with the long one first, got " + strconv.Itoa(len(lines)))
+	}
+}
+
+func TestReadLinesLongerThanBuffer(t *testing.T) {
+	_, err := ReadLines(strings.NewReader(strings.Repeat("a", 1000)), 100)
+
+	if err == nil {
+		t.Error("Expected error for a line longer than the buffer")
+	}
+}
+
+func TestReadHexAndBase64Lines(t *testing.T) {
+	hexLines, err := ReadInput(strings.NewReader("00ff\n\n0a0b0c\n"), "hex", 0)
+	if err != nil || len(hexLines) != 2 || !bytes.Equal(hexLines[0], []byte{0x00, 0xff}) {
+		t.Error("Expected 2 hex elements, got " + strconv.Itoa(len(hexLines)))
+	}
+
+	base64Lines, err := ReadInput(strings.NewReader("AP8=\nCgsM\n"), "base64", 0)
+	if err != nil || len(base64Lines) != 2 || !bytes.Equal(base64Lines[1], []byte{0x0a, 0x0b, 0x0c}) {
+		t.Error("Expected 2 base64 elements, got " + strconv.Itoa(len(base64Lines)))
+	}
+
+	if _, err := ReadInput(strings.NewReader("zz\n"), "hex", 0); err == nil {
+		t.Error("Expected error for invalid hex")
+	}
+}
+
+func TestReadPrefixedRecords(t *testing.T) {
+	var input []byte
+	for _, record := range []string{"A\nB", "", "\x00\x01"} {
+		input = binary.BigEndian.AppendUint32(input, uint32(len(record)))
+		input = append(input, record...)
+	}
+
+	records, err := ReadPrefixed(bytes.NewReader(input))
+
+	if err != nil {
+		t.Error("Expected no error, got " + err.Error())
+	}
+
+	if len(records) != 3 || string(records[0]) != "A\nB" || len(records[1]) != 0 {
+		t.Error("Expected 3 records, got " + strconv.Itoa(len(records)))
+	}
+
+	if _, err := ReadPrefixed(bytes.NewReader(input[:len(input)-1])); err == nil {
+		t.Error("Expected error for a truncated record")
+	}
+}
+
+func TestReadPrefixedHugeLength(t *testing.T) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	before := stats.TotalAlloc
+
+	// a length of 4 GiB before two bytes of data
+	if _, err := ReadPrefixed(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff, 'a', 'b'})); err == nil {
+		t.Error("Expected error for a truncated record")
+	}
+
+	runtime.ReadMemStats(&stats)
+	if stats.TotalAlloc-before > 1<<20 {
+		t.Error("Expected memory bounded by the input, got " + strconv.FormatUint(stats.TotalAlloc-before, 10) + " bytes")
+	}
+}
+
+// testBlock lays out a block with a version 1 header, zero otherwise, and
+// the given transactions.
+func testBlock(transactions [][]byte) []byte {
+	block := make([]byte, BlockHeaderSize)
+	block[0] = 1
+	block = append(block, byte(len(transactions)))
+	for _, tx := range transactions {
+		block = append(block, tx...)
+	}
+	return block
+}
+
+func testTransactions(n int) [][]byte {
+	data, _ := GenerateData("bitcoin", n, 1, 1, 0, 1)
+	var transactions [][]byte
+	for _, tx := range data {
+		raw, _ := hex.DecodeString(tx)
+		transactions = append(transactions, raw)
+	}
+	return transactions
+}
+
+func TestReadBlocksOneAfterTheOther(t *testing.T) {
+	transactions := testTransactions(5)
+	input := append(testBlock(transactions[:2]), testBlock(transactions[2:])...)
+
+	read, err := ReadBlocks(bytes.NewReader(input))
+
+	if err != nil {
+		t.Error("Expected no error, got " + err.Error())
+	}
+
+	if len(read) != 5 {
+		t.Error("Expected 5 transactions, got " + strconv.Itoa(len(read)))
+	}
+
+	for i := range read {
+		if !bytes.Equal(read[i], transactions[i]) {
+			t.Error("Expected transaction " + strconv.Itoa(i) + " to be read unchanged")
+		}
+	}
+}
+
+func TestReadBlocksFromBlockFile(t *testing.T) {
+	block := testBlock(testTransactions(3))
+	input := []byte{0xf9, 0xbe, 0xb4, 0xd9}
+	input = binary.LittleEndian.AppendUint32(input, uint32(len(block)))
+	input = append(input, block...)
+	input = append(input, make([]byte, 16)...)
+
+	read, err := ReadBlocks(bytes.NewReader(input))
+
+	if err != nil || len(read) != 3 {
+		t.Error("Expected 3 transactions, got " + strconv.Itoa(len(read)))
+	}
+}
+
+func TestReadSegwitTransaction(t *testing.T) {
+	tx, _ := hex.DecodeString("02000000" + "0001" + "01" + strings.Repeat("11", 36) + "00" + "ffffffff" +
+		"01" + "0100000000000000" + "160014" + strings.Repeat("22", 20) +
+		"02" + "03aabbcc" + "01dd" + "00000000")
+
+	block, err := ParseBlock(testBlock([][]byte{tx}))
+
+	if err != nil {
+		t.Error("Expected no error, got " + err.Error())
+	} else if len(block.Transactions) != 1 || !bytes.Equal(block.Transactions[0], tx) {
+		t.Error("Expected the segwit transaction with its witness")
+	}
+
+	if _, err := ParseBlock(append(testBlock([][]byte{tx}), 0)); err == nil {
+		t.Error("Expected error for trailing bytes")
+	}
+}
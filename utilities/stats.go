@@ -0,0 +1,250 @@
+package utilities
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Summary describes the distribution of the trials of one result column.
+// CILow and CIHigh bound the bootstrap confidence interval of the mean.
+type Summary struct {
+	Count  int     `json:"count"`
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	StdDev float64 `json:"stddev"`
+	P95    float64 `json:"p95"`
+	P99    float64 `json:"p99"`
+	CILow  float64 `json:"ci_low"`
+	CIHigh float64 `json:"ci_high"`
+}
+
+// bootstrapResamples is the number of resamples drawn to estimate the
+// confidence interval of the mean.
+const bootstrapResamples = 1000
+
+// Summarize computes the summary of the trials with a 95% confidence
+// interval. The resamples are drawn with the given seed so that the same
+// trials always give the same interval.
+func Summarize(trials []int64, seed int64) Summary {
+	if len(trials) == 0 {
+		return Summary{}
+	}
+
+	values := make([]float64, len(trials))
+	for i, trial := range trials {
+		values[i] = float64(trial)
+	}
+
+	mean := Mean(values)
+	low, high := BootstrapInterval(values, 0.95, bootstrapResamples, seed)
+
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	return Summary{
+		Count:  len(values),
+		Mean:   mean,
+		Median: Percentile(sorted, 50),
+		StdDev: StdDev(values),
+		P95:    Percentile(sorted, 95),
+		P99:    Percentile(sorted, 99),
+		CILow:  low,
+		CIHigh: high,
+	}
+}
+
+func Mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	return sum / float64(len(values))
+}
+
+// StdDev returns the sample standard deviation, zero for less than two values.
+func StdDev(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+
+	mean := Mean(values)
+	sum := 0.0
+	for _, value := range values {
+		sum += (value - mean) * (value - mean)
+	}
+	return math.Sqrt(sum / float64(len(values)-1))
+}
+
+// Percentile returns the p-th percentile of sorted values, interpolating
+// linearly between the two closest ranks.
+func Percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower < 0 {
+		return sorted[0]
+	}
+	if upper >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[lower] + (rank-float64(lower))*(sorted[upper]-sorted[lower])
+}
+
+// BootstrapInterval estimates the confidence interval of the mean by
+// resampling the values with replacement and taking the percentiles of the
+// resampled means.
+func BootstrapInterval(values []float64, confidence float64, resamples int, seed int64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+
+	r := rand.New(rand.NewSource(seed))
+	means := make([]float64, resamples)
+	for i := range means {
+		sum := 0.0
+		for j := 0; j < len(values); j++ {
+			sum += values[r.Intn(len(values))]
+		}
+		means[i] = sum / float64(len(values))
+	}
+	sort.Float64s(means)
+
+	alpha := (1 - confidence) / 2 * 100
+	return Percentile(means, alpha), Percentile(means, 100-alpha)
+}
+
+// Median returns the median of the trials without modifying them.
+func Median(trials []int64) int64 {
+	if len(trials) == 0 {
+		return 0
+	}
+
+	sorted := make([]float64, len(trials))
+	for i, trial := range trials {
+		sorted[i] = float64(trial)
+	}
+	sort.Float64s(sorted)
+
+	return int64(Percentile(sorted, 50))
+}
+
+// Complexity models fitted by FitComplexity.
+var ComplexityModels = []string{"O(1)", "O(log n)", "O(n)", "O(n log n)"}
+
+// Fit is the least squares fit of y = A * f(n) + B for the model f.
+type Fit struct {
+	Model string
+	A     float64
+	B     float64
+	R2    float64
+}
+
+func complexity(model string, n float64) float64 {
+	switch model {
+	case "O(log n)":
+		return math.Log2(n)
+	case "O(n)":
+		return n
+	case "O(n log n)":
+		return n * math.Log2(n)
+	}
+	return 1
+}
+
+// FitComplexity fits the values measured at the input sizes to every
+// complexity model. The residuals are weighted by the inverse of the values
+// so that the small sizes count as much as the large ones, otherwise any
+// growing model fits the largest sizes alike. The constant model is the
+// mean and explains nothing of the variance, so its R2 is zero.
+func FitComplexity(sizes []int, values []float64) []Fit {
+	weights := make([]float64, len(values))
+	for i, y := range values {
+		weights[i] = 1
+		if y != 0 {
+			weights[i] = 1 / (y * y)
+		}
+	}
+	mean := weightedMean(values, weights)
+
+	var total float64
+	for i, y := range values {
+		total += weights[i] * (y - mean) * (y - mean)
+	}
+
+	fits := []Fit{{Model: "O(1)", B: Mean(values)}}
+	for _, model := range ComplexityModels[1:] {
+		x := make([]float64, len(sizes))
+		for i, n := range sizes {
+			x[i] = complexity(model, float64(n))
+		}
+		xMean := weightedMean(x, weights)
+
+		var cov, variance float64
+		for i := range x {
+			cov += weights[i] * (x[i] - xMean) * (values[i] - mean)
+			variance += weights[i] * (x[i] - xMean) * (x[i] - xMean)
+		}
+		if variance == 0 || total == 0 {
+			fits = append(fits, Fit{Model: model, B: Mean(values)})
+			continue
+		}
+
+		a := cov / variance
+		b := mean - a*xMean
+		var residual float64
+		for i := range x {
+			e := values[i] - (a*x[i] + b)
+			residual += weights[i] * e * e
+		}
+		fits = append(fits, Fit{model, a, b, 1 - residual/total})
+	}
+	return fits
+}
+
+func weightedMean(values []float64, weights []float64) float64 {
+	var sum, total float64
+	for i, value := range values {
+		sum += weights[i] * value
+		total += weights[i]
+	}
+	return sum / total
+}
+
+// minGrowthR2 is the share of the variance a growing model has to explain
+// to be preferred over the constant one.
+const minGrowthR2 = 0.9
+
+// fitTolerance is how much less variance a simpler model may explain than
+// the best one and still be preferred, since O(n) and O(n log n) are hard
+// to tell apart over a few sizes.
+const fitTolerance = 0.005
+
+// BestFit returns the simplest growing model explaining nearly as much of
+// the variance as the best one, or the constant model when none of them
+// explains the values well enough.
+func BestFit(fits []Fit) Fit {
+	best := fits[0]
+	for _, fit := range fits[1:] {
+		if fit.A > 0 && fit.R2 >= minGrowthR2 && fit.R2 > best.R2 {
+			best = fit
+		}
+	}
+
+	// the fits are ordered from the simplest model
+	for _, fit := range fits[1:] {
+		if fit.A > 0 && fit.R2 >= minGrowthR2 && fit.R2 >= best.R2-fitTolerance {
+			return fit
+		}
+	}
+	return best
+}
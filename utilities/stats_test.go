@@ -0,0 +1,98 @@
+package utilities
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func format(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func TestSummarizeWithSampleTrials(t *testing.T) {
+	trials := []int64{2, 4, 4, 4, 5, 5, 7, 9}
+
+	s := Summarize(trials, 1)
+
+	if s.Count != 8 {
+		t.Error("Expected count 8, got " + strconv.Itoa(s.Count))
+	}
+
+	if s.Mean != 5 {
+		t.Error("Expected mean 5, got " + format(s.Mean))
+	}
+
+	if s.Median != 4.5 {
+		t.Error("Expected median 4.5, got " + format(s.Median))
+	}
+
+	if math.Abs(s.StdDev-2.138089935) > 1e-6 {
+		t.Error("Expected standard deviation 2.138089935, got " + format(s.StdDev))
+	}
+
+	if s.CILow > s.Mean || s.CIHigh < s.Mean {
+		t.Error("Expected the confidence interval to contain the mean, got [" + format(s.CILow) + ", " + format(s.CIHigh) + "]")
+	}
+}
+
+func TestSummarizeWithNoTrials(t *testing.T) {
+	s := Summarize(nil, 1)
+
+	if s != (Summary{}) {
+		t.Error("Expected an empty summary")
+	}
+}
+
+func TestPercentileInterpolates(t *testing.T) {
+	sorted := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}
+
+	if p := Percentile(sorted, 95); p != 10.5 {
+		t.Error("Expected 10.5, got " + format(p))
+	}
+
+	if p := Percentile(sorted, 0); p != 1 {
+		t.Error("Expected 1, got " + format(p))
+	}
+
+	if p := Percentile(sorted, 100); p != 11 {
+		t.Error("Expected 11, got " + format(p))
+	}
+}
+
+func TestBootstrapIntervalIsDeterministic(t *testing.T) {
+	values := []float64{1, 5, 3, 8, 2, 9, 4}
+
+	l1, h1 := BootstrapInterval(values, 0.95, 200, 7)
+	l2, h2 := BootstrapInterval(values, 0.95, 200, 7)
+
+	if l1 != l2 || h1 != h2 {
+		t.Error("Expected the same interval for the same seed")
+	}
+
+	if l1 > h1 {
+		t.Error("Expected the lower bound below the upper bound")
+	}
+}
+
+func TestMedianOfOddTrials(t *testing.T) {
+	if m := Median([]int64{9, 1, 5}); m != 5 {
+		t.Error("Expected 5, got " + strconv.FormatInt(m, 10))
+	}
+}
+
+func TestBestFitFindsTheComplexity(t *testing.T) {
+	sizes := []int{100, 1000, 10000, 100000}
+	expected := map[string][]float64{
+		"O(1)":       {5, 5, 5, 5},
+		"O(log n)":   {7, 10, 14, 17},
+		"O(n)":       {210, 2010, 20010, 200010},
+		"O(n log n)": {664, 9966, 132877, 1660964},
+	}
+
+	for model, values := range expected {
+		if fit := BestFit(FitComplexity(sizes, values)); fit.Model != model {
+			t.Error("Expected " + model + ", got " + fit.Model)
+		}
+	}
+}
@@ -2,45 +2,188 @@ package utilities
 
 import (
 	"flag"
-	"log"
-	"os"
-	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 )
 
-func ParseCommand() (*string, *string, *string, *int) {
+// Command holds the arguments of an experiment run.
+type Command struct {
+	Algorithm         string
+	Operation         string
+	FileName          string
+	Iterations        int
+	Parameters        []int
+	FalsePositiveRate float64
+	Positions         string
+	Samples           int
+	Seed              int64
+	Warmup            int
+	SubtractOverhead  bool
+	Format            string
+	InputFormat       string
+	MaxLineSize       int
+	InputDir          string
+	OutputDir         string
+}
+
+// ParseCommand defines the experiment flags on flags and parses args. An
+// empty op adds the -op flag, otherwise the operation is fixed to op and the
+// build operation has no flags for the elements to prove.
+func ParseCommand(flags *flag.FlagSet, op string, args []string) (*Command, error) {
 
 	// Parse algorithm:
 	// hl -> hashlist
+	// chl -> checkpointed hashlist
 	// mt -> Merkle tree (default)
 	// fmt -> fast Merkle tree
-	// bf -> Bloom's filter
-	algorithm := flag.String("algo", "mt", "the algorithm to use")
+	// amt -> array-backed Merkle tree
+	// kmt -> k-ary Merkle tree
+	// bf -> Bloom's filter (sized with -fpr)
+	// cf -> cuckoo filter
+	// xf -> xor filter
+	algorithm := flags.String("algo", "mt", "the algorithm to use")
 
 	// Parse operation:
-	// build -> build the data structure (default)
-	// verify -> verification of block
-	operation := flag.String("op", "build", "the operation to perform")
+	// build -> build the data structure
+	// prove -> generate the proof of an element
+	// verify -> check a proof generated beforehand
+	// all -> the three phases one after the other (default)
+	operation := &op
+	if op == "" {
+		operation = flags.String("op", "all", "the operation to perform (build, prove, verify or all)")
+	}
 
-	// Parse output file name
-	fileName := flag.String("name", "pew", "the name of the input file")
+	// Parse input file name, a glob pattern runs the experiment on every
+	// matching file and - reads stdin
+	fileName := flags.String("name", "pew", "the name of the input file, a glob pattern or - for stdin")
+
+	// Parse the folders of the input and result files
+	inputDir, outputDir := PathFlags(flags)
 
 	// Parse output file name
-	iterations := flag.Int("iter", 10, "number of iterations")
+	iterations := flags.Int("iter", 10, "number of iterations")
 
-	flag.Parse()
+	// Parse structure parameters, a comma separated list to sweep:
+	// chl -> checkpoint interval (default sqrt of the input size)
+	// kmt -> arity between 2 and 16 (default 2,4,8,16)
+	parameters := flags.String("k", "", "the structure parameters to sweep (checkpoint interval for chl, arity for kmt)")
 
-	return algorithm, operation, fileName, iterations
-}
+	// Parse target false positive rate of the filters
+	fpr := flags.Float64("fpr", 0.01, "the target false positive rate of the filters")
+
+	// Parse the elements to prove and verify:
+	// middle -> the element in the middle of the data (default)
+	// ends -> the first, middle and last elements
+	// all -> every element
+	// random -> -samples elements drawn uniformly using -seed
+	// worst -> the element with the longest proof
+	positions, samples, seed := new(string), new(int), new(int64)
+	if op != "build" {
+		positions = flags.String("pos", "middle", "the elements to prove and verify (middle, ends, all, random or worst)")
+		samples = flags.Int("samples", 10, "number of elements drawn with -pos=random")
+		seed = flags.Int64("seed", 1, "seed of the elements drawn with -pos=random")
+	}
 
-func GetPath() string {
-	dir, err := filepath.Abs(filepath.Dir(os.Args[0]))
+	// Parse the iterations run before the measured ones, whose results are
+	// discarded so that caches and the allocator settle first
+	warmup := flags.Int("warmup", 0, "number of warm-up iterations to discard")
 
+	// Parse whether to subtract the median overhead of reading the timer,
+	// measured like the time experiment, from the time results
+	overhead := flags.Bool("overhead", false, "subtract the timer overhead from the time results")
+
+	// Parse the format of the result files:
+	// txt -> comma separated values without header (default)
+	// csv -> comma separated values with a header
+	// json -> the results together with the metadata of the run
+	format := flags.String("format", "txt", "the format of the result files (csv, json or txt)")
+
+	// Parse the format of the input file, see InputFormats
+	inputFormat, maxLineSize := InputFlags(flags)
+
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	k, err := ParseIntList(*parameters)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+
+	return &Command{
+		Algorithm:         *algorithm,
+		Operation:         *operation,
+		FileName:          *fileName,
+		Iterations:        *iterations,
+		Parameters:        k,
+		FalsePositiveRate: *fpr,
+		Positions:         *positions,
+		Samples:           *samples,
+		Seed:              *seed,
+		Warmup:            *warmup,
+		SubtractOverhead:  *overhead,
+		Format:            *format,
+		InputFormat:       *inputFormat,
+		MaxLineSize:       *maxLineSize,
+		InputDir:          *inputDir,
+		OutputDir:         *outputDir,
+	}, nil
+}
+
+// InputFlags defines the flags selecting how the input is read, see
+// ReadInput.
+func InputFlags(flags *flag.FlagSet) (*string, *int) {
+	format := flags.String("input", "text", "the format of the input ("+strings.Join(InputFormats, ", ")+")")
+	maxLineSize := flags.Int("bufsize", DefaultMaxLineSize, "the longest line of the text, hex and base64 inputs in bytes")
+	return format, maxLineSize
+}
+
+// PathFlags defines the flags of the folders the input files are read from
+// and the result files are written to, relative to the working directory.
+func PathFlags(flags *flag.FlagSet) (*string, *string) {
+	in := flags.String("in", "source", "the folder of the input files")
+	out := flags.String("out", "results", "the folder of the result files, - for stdout")
+	return in, out
+}
+
+// ParseIntList parses a comma separated list of integers such as "2,4,8".
+// An empty string results in an empty list.
+func ParseIntList(list string) ([]int, error) {
+	var values []int
+
+	for _, field := range strings.Split(list, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		value, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
 	}
 
-	return dir
+	return values, nil
+}
+
+// MemUsage is a snapshot of the allocator counters: the cumulative bytes
+// and number of heap objects allocated, and the bytes of live heap objects.
+type MemUsage struct {
+	Allocated   int64
+	Allocations int64
+	Live        int64
+}
+
+func GetMemStats() MemUsage {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	return MemUsage{
+		Allocated:   int64(m.TotalAlloc),
+		Allocations: int64(m.Mallocs),
+		Live:        int64(m.HeapAlloc),
+	}
 }
 
 func GetMemUsage() int64 {